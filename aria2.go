@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// keys of aria2 downloads to query
+var aria2StatusKeys []string = []string{
+	"gid",
+	"status",
+	"totalLength",
+	"completedLength",
+	"downloadSpeed",
+	"uploadSpeed",
+	"uploadLength",
+	"infoHash",
+	"dir",
+	"errorMessage",
+	"bittorrent",
+	"followedBy",
+}
+
+// aria2 JSON-RPC request
+type aria2Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// aria2 JSON-RPC response
+type aria2Response struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// aria2 download
+//
+// (numbers are returned as strings)
+type aria2Download struct {
+	GID             string   `json:"gid"`
+	Status          string   `json:"status"` // active, waiting, paused, error, complete, or removed
+	TotalLength     string   `json:"totalLength"`
+	CompletedLength string   `json:"completedLength"`
+	DownloadSpeed   string   `json:"downloadSpeed"`
+	UploadSpeed     string   `json:"uploadSpeed"`
+	UploadLength    string   `json:"uploadLength"`
+	InfoHash        string   `json:"infoHash"`
+	Dir             string   `json:"dir"`
+	ErrorMessage    string   `json:"errorMessage"`
+	FollowedBy      []string `json:"followedBy"` // (gids of downloads started by this one, eg. torrents of magnet links)
+	BitTorrent      *struct {
+		AnnounceList [][]string `json:"announceList"`
+		Info         *struct {
+			Name string `json:"name"`
+		} `json:"info"`
+	} `json:"bittorrent"`
+	Files []struct {
+		Path            string `json:"path"`
+		Length          string `json:"length"`
+		CompletedLength string `json:"completedLength"`
+	} `json:"files"`
+}
+
+// aria2 peer
+type aria2Peer struct {
+	IP            string `json:"ip"`
+	Port          string `json:"port"`
+	DownloadSpeed string `json:"downloadSpeed"`
+	UploadSpeed   string `json:"uploadSpeed"`
+	Seeder        string `json:"seeder"`
+}
+
+// torrent client for aria2 JSON-RPC
+//
+// https://aria2.github.io/manual/en/html/aria2c.html#rpc-interface
+type aria2Client struct {
+	rpc    cfg.TransmissionRPCEndpoint
+	ids    *torrentIDMap
+	client *http.Client
+	err    error // error while creating http client
+}
+
+// create a new aria2 client
+func newAria2Client(rpc cfg.TransmissionRPCEndpoint) *aria2Client {
+	c := &aria2Client{
+		rpc: rpc,
+		ids: newTorrentIDMap(),
+	}
+	c.client, c.err = newHTTPClient(rpc)
+
+	return c
+}
+
+// call a method of aria2 JSON-RPC and decode its result into `v`
+func (c *aria2Client) call(ctx context.Context, method string, v any, params ...any) (err error) {
+	// prepend secret token
+	if len(c.rpc.Passwd) > 0 {
+		params = append([]any{"token:" + c.rpc.Passwd}, params...)
+	}
+
+	var data []byte
+	if data, err = json.Marshal(aria2Request{
+		JSONRPC: "2.0",
+		ID:      consts.TorrentClientAria2,
+		Method:  method,
+		Params:  params,
+	}); err != nil {
+		return err
+	}
+
+	if c.err != nil {
+		return c.err
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, "POST", c.rpc.URL(), bytes.NewBuffer(data)); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp *http.Response
+	if resp, err = c.client.Do(req); err != nil {
+		log.Printf("error while sending request to aria2: %s\n", err.Error())
+
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	res, _ := io.ReadAll(resp.Body)
+
+	var response aria2Response
+	if err = json.Unmarshal(res, &response); err != nil {
+		return fmt.Errorf("HTTP %d (%s)", resp.StatusCode, string(res))
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s failed: %s", method, response.Error.Message)
+	}
+	if v != nil {
+		if err = json.Unmarshal(response.Result, v); err != nil {
+			return fmt.Errorf("malformed response from aria2: %s", string(response.Result))
+		}
+	}
+
+	return nil
+}
+
+// convert aria2 download to transmission's torrent
+func (c *aria2Client) convert(d aria2Download) (torrent RPCResponseTorrent) {
+	total, _ := strconv.ParseInt(d.TotalLength, 10, 64)
+	completed, _ := strconv.ParseInt(d.CompletedLength, 10, 64)
+	uploaded, _ := strconv.ParseInt(d.UploadLength, 10, 64)
+	down, _ := strconv.ParseInt(d.DownloadSpeed, 10, 64)
+	up, _ := strconv.ParseInt(d.UploadSpeed, 10, 64)
+
+	torrent = RPCResponseTorrent{
+		ID:           c.ids.id(d.GID),
+		RateDownload: down,
+		RateUpload:   up,
+		TotalSize:    total,
+		HashString:   d.InfoHash,
+		DownloadDir:  d.Dir,
+		ETA:          -1,
+		UploadRatio:  -1,
+	}
+	if total > 0 {
+		torrent.PercentDone = float32(float64(completed) / float64(total))
+		torrent.UploadRatio = float64(uploaded) / float64(total)
+	}
+	if down > 0 {
+		torrent.ETA = (total - completed) / down
+	}
+
+	// name
+	if d.BitTorrent != nil && d.BitTorrent.Info != nil {
+		torrent.Name = d.BitTorrent.Info.Name
+	} else if len(d.Files) > 0 {
+		torrent.Name = filepath.Base(d.Files[0].Path)
+	}
+
+	// status
+	switch d.Status {
+	case "active":
+		if total > 0 && completed >= total {
+			torrent.Status = TorrentStatusSeeding
+		} else {
+			torrent.Status = TorrentStatusDownloading
+		}
+	case "waiting":
+		torrent.Status = TorrentStatusQueuedToDownload
+	case "error":
+		torrent.Status = TorrentStatusStopped
+		torrent.Error = d.ErrorMessage
+	default: // paused, complete
+		torrent.Status = TorrentStatusStopped
+	}
+
+	// files and trackers
+	for _, f := range d.Files {
+		length, _ := strconv.ParseInt(f.Length, 10, 64)
+		completed, _ := strconv.ParseInt(f.CompletedLength, 10, 64)
+		path := f.Path
+		if rel, err := filepath.Rel(d.Dir, f.Path); err == nil {
+			path = rel
+		}
+		torrent.Files = append(torrent.Files, RPCResponseTorrentFile{
+			Name:           path,
+			Length:         length,
+			BytesCompleted: completed,
+		})
+	}
+	if d.BitTorrent != nil {
+		for _, tier := range d.BitTorrent.AnnounceList {
+			for _, announce := range tier {
+				host := announce
+				if u, err := url.Parse(announce); err == nil && len(u.Host) > 0 {
+					host = u.Host
+				}
+				torrent.TrackerStats = append(torrent.TrackerStats, RPCResponseTorrentTracker{
+					ID:       len(torrent.TrackerStats),
+					Host:     host,
+					Announce: announce,
+				})
+			}
+		}
+	}
+
+	return torrent
+}
+
+// get all downloads of torrents
+func (c *aria2Client) getDownloads(ctx context.Context) (downloads []aria2Download, err error) {
+	var active, waiting, stopped []aria2Download
+	if err = c.call(ctx, "aria2.tellActive", &active, aria2StatusKeys); err != nil {
+		return nil, err
+	}
+	if waiting, err = c.getAllDownloads(ctx, "aria2.tellWaiting"); err != nil {
+		return nil, err
+	}
+	if stopped, err = c.getAllDownloads(ctx, "aria2.tellStopped"); err != nil {
+		return nil, err
+	}
+
+	all := append(append(active, waiting...), stopped...)
+
+	// (ids of metadata downloads of magnets will point to their torrents)
+	for _, d := range all {
+		if len(d.FollowedBy) > 0 {
+			c.ids.follow(d.GID, d.FollowedBy[0])
+		}
+	}
+
+	for _, d := range all {
+		// only torrents (not http/ftp downloads, nor metadata of magnets)
+		if d.BitTorrent != nil && d.BitTorrent.Info != nil {
+			downloads = append(downloads, d)
+		}
+	}
+	return downloads, nil
+}
+
+// get all downloads with given method (`aria2.tellWaiting` or `aria2.tellStopped`), page by page
+func (c *aria2Client) getAllDownloads(ctx context.Context, method string) (downloads []aria2Download, err error) {
+	for offset := 0; ; offset += consts.Aria2PageSize {
+		var page []aria2Download
+		if err = c.call(ctx, method, &page, offset, consts.Aria2PageSize, aria2StatusKeys); err != nil {
+			return nil, err
+		}
+		downloads = append(downloads, page...)
+
+		if len(page) < consts.Aria2PageSize {
+			return downloads, nil
+		}
+	}
+}
+
+// GetTorrents retrieves all torrents.
+func (c *aria2Client) GetTorrents(ctx context.Context) (torrents []RPCResponseTorrent, err error) {
+	var downloads []aria2Download
+	if downloads, err = c.getDownloads(ctx); err != nil {
+		return nil, err
+	}
+
+	torrents = []RPCResponseTorrent{}
+	for _, d := range downloads {
+		t := c.convert(d)
+		t.Files, t.TrackerStats = nil, nil
+		torrents = append(torrents, t)
+	}
+	return torrents, nil
+}
+
+// GetTorrent retrieves a torrent with its details.
+func (c *aria2Client) GetTorrent(ctx context.Context, torrentID string) (torrent RPCResponseTorrent, err error) {
+	var gid string
+	if gid, err = c.ids.key(torrentID); err != nil {
+		return torrent, err
+	}
+
+	var d aria2Download
+	if err = c.call(ctx, "aria2.tellStatus", &d, gid, append(aria2StatusKeys, "files")); err != nil {
+		return torrent, err
+	}
+
+	// metadata download of a magnet, which was followed by its torrent
+	if (d.BitTorrent == nil || d.BitTorrent.Info == nil) && len(d.FollowedBy) > 0 {
+		c.ids.follow(gid, d.FollowedBy[0])
+
+		gid = d.FollowedBy[0]
+		d = aria2Download{}
+		if err = c.call(ctx, "aria2.tellStatus", &d, gid, append(aria2StatusKeys, "files")); err != nil {
+			return torrent, err
+		}
+	}
+	torrent = c.convert(d)
+
+	// peers (only for active downloads)
+	var peers []aria2Peer
+	if err := c.call(ctx, "aria2.getPeers", &peers, gid); err == nil {
+		for _, p := range peers {
+			down, _ := strconv.ParseInt(p.DownloadSpeed, 10, 64)
+			up, _ := strconv.ParseInt(p.UploadSpeed, 10, 64)
+			peer := RPCResponseTorrentPeer{
+				Address:      fmt.Sprintf("%s:%s", p.IP, p.Port),
+				RateToClient: down,
+				RateToPeer:   up,
+			}
+			if p.Seeder == "true" {
+				peer.Progress = 1.0
+			}
+			torrent.Peers = append(torrent.Peers, peer)
+		}
+	}
+
+	return torrent, nil
+}
+
+// AddTorrent adds a torrent.
+func (c *aria2Client) AddTorrent(ctx context.Context, torrent TorrentSource, downloadDir string, paused bool) (string, *RPCResponseTorrent, bool) {
+	options := map[string]string{}
+	if len(downloadDir) > 0 {
+		options["dir"] = downloadDir
+	}
+	if paused {
+		options["pause"] = "true"
+	}
+
+	var gid string
+	var err error
+	if len(torrent.Metainfo) > 0 {
+		err = c.call(ctx, "aria2.addTorrent", &gid, base64.StdEncoding.EncodeToString(torrent.Metainfo), []string{}, options)
+	} else {
+		err = c.call(ctx, "aria2.addUri", &gid, []string{torrent.Filename}, options)
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to add given torrent: %s", err), nil, false
+	}
+
+	// (downloads of magnets and urls are not torrents until their metadata are fetched)
+	var added *RPCResponseTorrent
+	if len(torrent.Metainfo) > 0 {
+		var d aria2Download
+		if err := c.call(ctx, "aria2.tellStatus", &d, gid, aria2StatusKeys); err == nil {
+			t := c.convert(d)
+			added = &t
+		}
+	} else if metadata, err := parseMagnet(torrent.Filename); err == nil {
+		// (only with the values known from the magnet link,
+		// and its id will point to the torrent which follows the metadata download)
+		added = &RPCResponseTorrent{
+			ID:         c.ids.id(gid),
+			Name:       metadata.Name,
+			HashString: metadata.InfoHash,
+		}
+	}
+
+	return "Given torrent was successfully added to the list.", added, true
+}
+
+// RemoveTorrent removes a torrent.
+//
+// (aria2 does not delete local data, so it fails when `deleteLocal` is true)
+func (c *aria2Client) RemoveTorrent(ctx context.Context, torrentID string, deleteLocal bool) (string, bool) {
+	if deleteLocal {
+		return fmt.Sprintf("Failed to delete given torrent: aria2 does not delete local data (use %s instead).", consts.CommandTransmissionRemove), false
+	}
+
+	gid, err := c.ids.key(torrentID)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	// remove download (fails when it is already stopped),
+	_ = c.call(ctx, "aria2.forceRemove", nil, gid)
+
+	// and its result
+	if err := c.call(ctx, "aria2.removeDownloadResult", nil, gid); err != nil {
+		return fmt.Sprintf("Failed to remove given torrent: %s", err), false
+	}
+
+	return removedMessage(torrentID, false), true
+}
+
+// PauseTorrent stops a torrent.
+func (c *aria2Client) PauseTorrent(ctx context.Context, torrentID string) string {
+	var err error
+	if torrentID == consts.ParamAllTorrents {
+		err = c.call(ctx, "aria2.forcePauseAll", nil)
+	} else {
+		var gid string
+		if gid, err = c.ids.key(torrentID); err == nil {
+			err = c.call(ctx, "aria2.forcePause", nil, gid)
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to pause given torrent: %s", err)
+	}
+	return pausedMessage(torrentID)
+}
+
+// ResumeTorrent starts a torrent.
+//
+// When `now` is true, the torrent will be moved to the front of the queue.
+func (c *aria2Client) ResumeTorrent(ctx context.Context, torrentID string, now bool) string {
+	var err error
+	if torrentID == consts.ParamAllTorrents {
+		err = c.call(ctx, "aria2.unpauseAll", nil)
+	} else {
+		var gid string
+		if gid, err = c.ids.key(torrentID); err == nil {
+			if err = c.call(ctx, "aria2.unpause", nil, gid); err == nil && now {
+				err = c.call(ctx, "aria2.changePosition", nil, gid, 0, "POS_SET")
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to resume given torrent: %s", err)
+	}
+	return resumedMessage(torrentID)
+}
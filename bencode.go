@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// constants for bencode
+const (
+	bencodeMaxDepth = 64
+)
+
+// bencode decoder
+//
+// https://www.bittorrent.org/beps/bep_0003.html#bencoding
+type bencodeDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+
+	rawInfo []byte // raw bytes of the top-level 'info' dictionary (for calculating info hash)
+}
+
+// decode all bytes as a single value
+//
+// decoded values will be one of: int64, string, []any, and map[string]any
+func (d *bencodeDecoder) decodeAll() (value any, err error) {
+	if value, err = d.decode(); err == nil && d.pos != len(d.data) {
+		err = fmt.Errorf("trailing data at offset %d", d.pos)
+	}
+
+	return value, err
+}
+
+// decode a value at current position
+func (d *bencodeDecoder) decode() (value any, err error) {
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		return d.decodeInt()
+	case c == 'l':
+		return d.decodeList()
+	case c == 'd':
+		return d.decodeDict()
+	case c >= '0' && c <= '9':
+		return d.decodeString()
+	default:
+		return nil, fmt.Errorf("unexpected character '%c' at offset %d", c, d.pos)
+	}
+}
+
+// decode an integer: i<number>e
+func (d *bencodeDecoder) decodeInt() (value int64, err error) {
+	end := bytes.IndexByte(d.data[d.pos:], 'e')
+	if end < 0 {
+		return 0, fmt.Errorf("unterminated integer at offset %d", d.pos)
+	}
+
+	if value, err = strconv.ParseInt(string(d.data[d.pos+1:d.pos+end]), 10, 64); err != nil {
+		return 0, fmt.Errorf("malformed integer at offset %d: %s", d.pos, err)
+	}
+	d.pos += end + 1
+
+	return value, nil
+}
+
+// decode a string: <length>:<contents>
+func (d *bencodeDecoder) decodeString() (value string, err error) {
+	colon := bytes.IndexByte(d.data[d.pos:], ':')
+	if colon < 0 {
+		return "", fmt.Errorf("unterminated string length at offset %d", d.pos)
+	}
+
+	var length int
+	if length, err = strconv.Atoi(string(d.data[d.pos : d.pos+colon])); err != nil || length < 0 {
+		return "", fmt.Errorf("malformed string length at offset %d", d.pos)
+	}
+
+	start := d.pos + colon + 1
+	if length > len(d.data)-start {
+		return "", fmt.Errorf("string length out of range at offset %d", d.pos)
+	}
+	d.pos = start + length
+
+	return string(d.data[start:d.pos]), nil
+}
+
+// decode a list: l<values>e
+func (d *bencodeDecoder) decodeList() (value []any, err error) {
+	if err = d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	d.pos++ // 'l'
+	value = []any{}
+	for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+		var v any
+		if v, err = d.decode(); err != nil {
+			return nil, err
+		}
+		value = append(value, v)
+	}
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("unterminated list")
+	}
+	d.pos++ // 'e'
+
+	return value, nil
+}
+
+// decode a dictionary: d<key><value>...e
+func (d *bencodeDecoder) decodeDict() (value map[string]any, err error) {
+	if err = d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	d.pos++ // 'd'
+	value = map[string]any{}
+	for d.pos < len(d.data) && d.data[d.pos] != 'e' {
+		var k string
+		if k, err = d.decodeString(); err != nil {
+			return nil, fmt.Errorf("malformed dictionary key: %s", err)
+		}
+
+		start := d.pos
+		var v any
+		if v, err = d.decode(); err != nil {
+			return nil, err
+		}
+		value[k] = v
+
+		if d.depth == 1 && k == "info" {
+			d.rawInfo = d.data[start:d.pos]
+		}
+	}
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("unterminated dictionary")
+	}
+	d.pos++ // 'e'
+
+	return value, nil
+}
+
+// increase depth of nested lists/dictionaries
+func (d *bencodeDecoder) enter() error {
+	d.depth++
+	if d.depth > bencodeMaxDepth {
+		return fmt.Errorf("too deeply nested at offset %d", d.pos)
+	}
+	return nil
+}
+
+// decrease depth of nested lists/dictionaries
+func (d *bencodeDecoder) leave() {
+	d.depth--
+}
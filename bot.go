@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
@@ -34,11 +37,14 @@ type status int16
 const (
 	StatusWaiting                   status = iota
 	StatusWaitingTransmissionUpload status = iota
+	StatusWaitingTrackerURL         status = iota
+	StatusWaitingImportFile         status = iota
 )
 
 type session struct {
 	UserID        string
 	CurrentStatus status
+	Command       string // command waiting for user input (eg. announce url of tracker)
 }
 
 type sessionPool struct {
@@ -48,6 +54,8 @@ type sessionPool struct {
 
 var pool sessionPool
 
+var errUnprocessableFileFormat = errors.New(consts.MessageUnprocessableFileFormat)
+
 // keyboards
 var allKeyboards = [][]bot.KeyboardButton{
 	{
@@ -68,6 +76,18 @@ var allKeyboards = [][]bot.KeyboardButton{
 			Style: new(bot.KeyboardStyleDanger),
 		},
 	},
+	{
+		{
+			Text: consts.CommandTransmissionPause,
+		},
+		{
+			Text:  consts.CommandTransmissionResume,
+			Style: new(bot.KeyboardStyleSuccess),
+		},
+		{
+			Text: consts.CommandTransmissionSpeed,
+		},
+	},
 	{
 		{
 			Text:  consts.CommandServiceStatus,
@@ -131,10 +151,29 @@ following commands are supported:
 
 *for transmission*
 
-%s : show torrent list
+%s : show torrent list (filter with 'downloading', 'seeding', 'stopped', 'error', or name, sort with 'sort:name|size|progress|rate|added')
+%s : show details of a torrent (files, peers, and trackers)
+%s : select files of a torrent to download
+%s : show/change speed limits and turtle mode
 %s : add torrent with url or magnet
 %s : remove torrent from list
 %s : remove torrent and delete data
+%s : pause torrent (or all torrents with 'all')
+%s : resume torrent (or all torrents with 'all', bypassing the queue with 'now')
+%s : remove seeded torrents by the cleanup policy (only list them with 'dry-run')
+%s : watch the progress of active torrents in a live-updating message
+%s : show/change the download queue (move torrents with 'top', 'up', 'down', or 'bottom', change its size with 'size')
+%s : verify local data of torrent(s) (id, 'all', or filters of %s)
+%s : reannounce torrent(s) to trackers (id, 'all', or filters of %s)
+%s : change location of torrent(s) to one of download locations (id, 'all', or filters of %s)
+%s : show/add/remove/replace trackers of a torrent (replace trackers of a host across all torrents with 'replace host url')
+%s : show downloading torrents whose progress has not advanced for a while
+%s : show session stats and transfer volumes (of recent days or months with 'daily' or 'monthly')
+%s : list/add/remove/pause/resume rss feeds for downloading torrents automatically
+%s : export the list of torrents as a .json file (or .csv with 'csv')
+%s : import torrents from an exported file (re-adding missing ones with their magnet links)
+
+(when there are multiple transmission instances, target instance can be specified with '@name')
 
 *for systemctl*
 
@@ -150,9 +189,26 @@ following commands are supported:
 %s : show this help message
 `,
 		consts.CommandTransmissionList,
+		consts.CommandTransmissionInfo,
+		consts.CommandTransmissionFiles,
+		consts.CommandTransmissionSpeed,
 		consts.CommandTransmissionAdd,
 		consts.CommandTransmissionRemove,
 		consts.CommandTransmissionDelete,
+		consts.CommandTransmissionPause,
+		consts.CommandTransmissionResume,
+		consts.CommandTransmissionClean,
+		consts.CommandTransmissionWatch,
+		consts.CommandTransmissionQueue,
+		consts.CommandTransmissionVerify, consts.CommandTransmissionList,
+		consts.CommandTransmissionReannounce, consts.CommandTransmissionList,
+		consts.CommandTransmissionLocation, consts.CommandTransmissionList,
+		consts.CommandTransmissionTrackers,
+		consts.CommandTransmissionStalled,
+		consts.CommandTransmissionStats,
+		consts.CommandRSS,
+		consts.CommandTransmissionExport,
+		consts.CommandTransmissionImport,
 		consts.CommandServiceStatus,
 		consts.CommandServiceStart,
 		consts.CommandServiceStop,
@@ -261,34 +317,91 @@ func parseServiceCommand(
 
 // parse transmission command
 func parseTransmissionCommand(
+	ctx context.Context,
 	config cfg.Config,
 	txt string,
 ) (message string, keyboards [][]bot.InlineKeyboardButton) {
-	if torrents, _ := GetTorrents(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd); len(torrents) > 0 {
-		for _, cmd := range []string{consts.CommandTransmissionRemove, consts.CommandTransmissionDelete} {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+
+	client := torrentClientFor(instance)
+
+	if torrents, _ := client.GetTorrents(ctx); len(torrents) > 0 {
+		for _, cmd := range []string{
+			consts.CommandTransmissionRemove,
+			consts.CommandTransmissionDelete,
+			consts.CommandTransmissionPause,
+			consts.CommandTransmissionResume,
+		} {
 			if strings.HasPrefix(txt, cmd) {
-				param := strings.TrimSpace(strings.Replace(txt, cmd, "", 1))
+				params := strings.Fields(strings.TrimSpace(strings.Replace(txt, cmd, "", 1)))
+				param := ""
+				if len(params) > 0 {
+					param = params[0]
+				}
 
-				if _, err := strconv.Atoi(param); err == nil { // if torrent id number is given,
-					if strings.HasPrefix(txt, consts.CommandTransmissionRemove) { // remove torrent
-						message = RemoveTorrent(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd, param)
-					} else if strings.HasPrefix(txt, consts.CommandTransmissionDelete) { // delete service
-						message = DeleteTorrent(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd, param)
+				allowAll := cmd == consts.CommandTransmissionPause || cmd == consts.CommandTransmissionResume
+
+				if _, err := strconv.Atoi(param); err == nil || (allowAll && param == consts.ParamAllTorrents) { // if torrent id number (or "all") is given,
+					switch cmd {
+					case consts.CommandTransmissionRemove: // remove torrent
+						message, _ = client.RemoveTorrent(ctx, param, false)
+					case consts.CommandTransmissionDelete: // delete torrent
+						message, _ = client.RemoveTorrent(ctx, param, true)
+					case consts.CommandTransmissionPause: // pause torrent
+						message = client.PauseTorrent(ctx, param)
+					case consts.CommandTransmissionResume: // resume torrent
+						now := len(params) > 1 && params[1] == consts.ParamResumeNow
+						message = client.ResumeTorrent(ctx, param, now)
 					}
 				} else {
-					if strings.HasPrefix(txt, consts.CommandTransmissionRemove) { // remove torrent
+					// filter torrents which are selectable for this command
+					selectable := []RPCResponseTorrent{}
+					switch cmd {
+					case consts.CommandTransmissionRemove: // remove torrent
 						message = consts.MessageTransmissionRemove
-					} else if strings.HasPrefix(txt, consts.CommandTransmissionDelete) { // delete torrent
+						selectable = torrents
+					case consts.CommandTransmissionDelete: // delete torrent
 						message = consts.MessageTransmissionDelete
+						selectable = torrents
+					case consts.CommandTransmissionPause: // pause torrent
+						message = consts.MessageTransmissionPause
+						for _, t := range torrents {
+							if t.Status != TorrentStatusStopped {
+								selectable = append(selectable, t)
+							}
+						}
+					case consts.CommandTransmissionResume: // resume torrent
+						message = consts.MessageTransmissionResume
+						for _, t := range torrents {
+							if t.Status == TorrentStatusStopped {
+								selectable = append(selectable, t)
+							}
+						}
+					}
+
+					if len(selectable) <= 0 {
+						return consts.MessageTransmissionNoTorrents, nil
 					}
 
 					// inline keyboards
 					keys := map[string]string{}
-					for _, t := range torrents {
-						keys[fmt.Sprintf("%d. %s", t.ID, t.Name)] = fmt.Sprintf("%s %d", cmd, t.ID)
+					for _, t := range selectable {
+						keys[fmt.Sprintf("%d. %s", t.ID, t.Name)] = fmt.Sprintf("%s %d", instanceCommand(instance, cmd), t.ID)
 					}
 					keyboards = bot.NewInlineKeyboardButtonsAsRowsWithCallbackData(keys)
 
+					// add 'all' button for pause/resume
+					if allowAll {
+						keyboards = append(keyboards, []bot.InlineKeyboardButton{
+							bot.NewInlineKeyboardButton(consts.MessageAllTorrents).
+								SetCallbackData(fmt.Sprintf("%s %s", instanceCommand(instance, cmd), consts.ParamAllTorrents)).
+								SetStyle(bot.KeyboardStylePrimary),
+						})
+					}
+
 					// add cancel button
 					keyboards = append(keyboards, []bot.InlineKeyboardButton{
 						bot.NewInlineKeyboardButton(consts.MessageCancel).
@@ -306,6 +419,372 @@ func parseTransmissionCommand(
 	return message, keyboards
 }
 
+// parse transmission info command
+//
+// `/trinfo [id] [page]`
+func parseTransmissionInfoCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+	cmd := instanceCommand(instance, consts.CommandTransmissionInfo)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionInfo, "", 1)))
+
+	// if no torrent id is given, show a picker
+	if len(params) <= 0 {
+		torrents, _ := torrentClientFor(instance).GetTorrents(ctx)
+		if len(torrents) <= 0 {
+			return consts.MessageTransmissionNoTorrents, nil
+		}
+
+		keys := map[string]string{}
+		for _, t := range torrents {
+			keys[fmt.Sprintf("%d. %s", t.ID, t.Name)] = fmt.Sprintf("%s %d 1", cmd, t.ID)
+		}
+		keyboards = bot.NewInlineKeyboardButtonsAsRowsWithCallbackData(keys)
+
+		// add cancel button
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(consts.MessageCancel).
+				SetCallbackData(consts.CommandCancel).
+				SetStyle(bot.KeyboardStyleDanger),
+		})
+
+		return consts.MessageTransmissionInfo, keyboards
+	}
+
+	torrentID := params[0]
+	if _, err := strconv.Atoi(torrentID); err != nil {
+		return fmt.Sprintf("not a valid torrent id: %s", torrentID), nil
+	}
+
+	page := 1
+	if len(params) > 1 {
+		if p, err := strconv.Atoi(params[1]); err == nil {
+			page = p
+		}
+	}
+
+	pages := paginateLines(
+		GetInfo(ctx, torrentClientFor(instance), torrentID),
+		consts.MaxMessageLength,
+	)
+	page = max(1, min(page, len(pages)))
+	message = pages[page-1]
+
+	// inline keyboards for navigating pages
+	buttons := []bot.InlineKeyboardButton{}
+	if page > 1 {
+		buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessagePrevPage).
+			SetCallbackData(fmt.Sprintf("%s %s %d", cmd, torrentID, page-1)))
+	}
+	buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessageRefresh).
+		SetCallbackData(fmt.Sprintf("%s %s %d", cmd, torrentID, page)))
+	if page < len(pages) {
+		buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessageNextPage).
+			SetCallbackData(fmt.Sprintf("%s %s %d", cmd, torrentID, page+1)))
+	}
+	keyboards = [][]bot.InlineKeyboardButton{
+		buttons,
+	}
+	if isTransmission(instance) {
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(consts.MessageTransmissionFilesButton).
+				SetCallbackData(fmt.Sprintf("%s %s 1", instanceCommand(instance, consts.CommandTransmissionFiles), torrentID)),
+		})
+	}
+
+	if len(pages) > 1 {
+		message += fmt.Sprintf("\n----\npage %d/%d", page, len(pages))
+	}
+
+	return message, keyboards
+}
+
+// parse transmission files command
+//
+// `/trfiles [id] [page] [action] [file index]`
+//
+// (action: `w` for toggling wanted, `p` for cycling priorities, and `d` for finishing selection)
+func parseTransmissionFilesCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+	client, ok := transmissionClientFor(instance)
+	if !ok {
+		return consts.MessageNotSupportedByClient, nil
+	}
+	cmd := instanceCommand(instance, consts.CommandTransmissionFiles)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionFiles, "", 1)))
+
+	// if no torrent id is given, show a picker
+	if len(params) <= 0 {
+		torrents, _ := client.GetTorrents(ctx)
+		if len(torrents) <= 0 {
+			return consts.MessageTransmissionNoTorrents, nil
+		}
+
+		keys := map[string]string{}
+		for _, t := range torrents {
+			keys[fmt.Sprintf("%d. %s", t.ID, t.Name)] = fmt.Sprintf("%s %d 1", cmd, t.ID)
+		}
+		keyboards = bot.NewInlineKeyboardButtonsAsRowsWithCallbackData(keys)
+
+		// add cancel button
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(consts.MessageCancel).
+				SetCallbackData(consts.CommandCancel).
+				SetStyle(bot.KeyboardStyleDanger),
+		})
+
+		return consts.MessageTransmissionFiles, keyboards
+	}
+
+	torrentID := params[0]
+	page := 1
+	if len(params) > 1 {
+		if p, err := strconv.Atoi(params[1]); err == nil {
+			page = p
+		}
+	}
+
+	torrent, err := client.GetTorrentFiles(ctx, torrentID)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if len(torrent.Files) <= 0 || len(torrent.Files) != len(torrent.FileStats) {
+		return consts.MessageTransmissionNoFiles, nil
+	}
+
+	// apply action
+	if len(params) > 2 {
+		action := params[2]
+
+		if action == consts.ParamFilesDone {
+			numWanted := 0
+			for _, f := range torrent.FileStats {
+				if f.Wanted {
+					numWanted++
+				}
+			}
+			return fmt.Sprintf("Torrent id: %s will download %d of %d file(s)", torrentID, numWanted, len(torrent.Files)), nil
+		}
+
+		index := -1
+		if len(params) > 3 {
+			if i, err := strconv.Atoi(params[3]); err == nil && i >= 0 && i < len(torrent.FileStats) {
+				index = i
+			}
+		}
+		if index < 0 {
+			return fmt.Sprintf("not a valid file index: %s", txt), nil
+		}
+
+		switch action {
+		case consts.ParamFilesToggleWanted:
+			err = client.SetFilesWanted(ctx, torrentID, []int{index}, !torrent.FileStats[index].Wanted)
+		case consts.ParamFilesCyclePriority:
+			var priority FilePriority
+			switch torrent.FileStats[index].Priority {
+			case FilePriorityNormal:
+				priority = FilePriorityHigh
+			case FilePriorityHigh:
+				priority = FilePriorityLow
+			default:
+				priority = FilePriorityNormal
+			}
+			err = client.SetFilesPriority(ctx, torrentID, []int{index}, priority)
+		default:
+			err = fmt.Errorf("not a valid action: %s", action)
+		}
+		if err != nil {
+			return err.Error(), nil
+		}
+
+		// fetch again for the updated states
+		if torrent, err = client.GetTorrentFiles(ctx, torrentID); err != nil {
+			return err.Error(), nil
+		}
+	}
+
+	numPages := (len(torrent.Files)-1)/consts.NumFilesPerPage + 1
+	page = max(1, min(page, numPages))
+
+	lines := []string{
+		fmt.Sprintf("*%d*. _%s_", torrent.ID, removeMarkdownChars(torrent.Name, " ")),
+	}
+	for i := (page - 1) * consts.NumFilesPerPage; i < min(page*consts.NumFilesPerPage, len(torrent.Files)); i++ {
+		file, stat := torrent.Files[i], torrent.FileStats[i]
+
+		lines = append(lines, fmt.Sprintf("  %d. %s %s %s (%s)",
+			i+1,
+			wantedToString(stat.Wanted),
+			priorityToString(stat.Priority),
+			removeMarkdownChars(file.Name, " "),
+			readableSize(file.Length),
+		))
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(fmt.Sprintf("%s %d. %s", wantedToString(stat.Wanted), i+1, truncateString(file.Name, consts.MaxButtonTextLength))).
+				SetCallbackData(fmt.Sprintf("%s %s %d %s %d", cmd, torrentID, page, consts.ParamFilesToggleWanted, i)),
+			bot.NewInlineKeyboardButton(priorityToString(stat.Priority)).
+				SetCallbackData(fmt.Sprintf("%s %s %d %s %d", cmd, torrentID, page, consts.ParamFilesCyclePriority, i)),
+		})
+	}
+	if numPages > 1 {
+		lines = append(lines, "----", fmt.Sprintf("page %d/%d", page, numPages))
+	}
+	message = strings.Join(lines, "\n")
+
+	// inline keyboards for navigating pages
+	buttons := []bot.InlineKeyboardButton{}
+	if page > 1 {
+		buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessagePrevPage).
+			SetCallbackData(fmt.Sprintf("%s %s %d", cmd, torrentID, page-1)))
+	}
+	buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessageDone).
+		SetCallbackData(fmt.Sprintf("%s %s %d %s", cmd, torrentID, page, consts.ParamFilesDone)).
+		SetStyle(bot.KeyboardStyleSuccess))
+	if page < numPages {
+		buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessageNextPage).
+			SetCallbackData(fmt.Sprintf("%s %s %d", cmd, torrentID, page+1)))
+	}
+	keyboards = append(keyboards, buttons)
+
+	return message, keyboards
+}
+
+// convert wanted flag of a file to string
+func wantedToString(wanted bool) string {
+	if wanted {
+		return `✅`
+	}
+	return `⬜`
+}
+
+// parse transmission speed command
+//
+// `/trspeed [turtle on|off] [down KB/s|off] [up KB/s|off]`
+func parseTransmissionSpeedCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+	client, ok := transmissionClientFor(instance)
+	if !ok {
+		return consts.MessageNotSupportedByClient, nil
+	}
+	cmd := instanceCommand(instance, consts.CommandTransmissionSpeed)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionSpeed, "", 1)))
+
+	// apply changes
+	var result string
+	if len(params) > 0 {
+		if len(params) < 2 {
+			return fmt.Sprintf("not a valid speed command: %s", txt), nil
+		}
+
+		arguments := map[string]any{}
+		switch params[0] {
+		case consts.ParamSpeedTurtle:
+			arguments["alt-speed-enabled"] = params[1] == consts.ParamOn
+		case consts.ParamSpeedDown, consts.ParamSpeedUp:
+			direction := "down"
+			if params[0] == consts.ParamSpeedUp {
+				direction = "up"
+			}
+
+			if params[1] == consts.ParamOff {
+				arguments[fmt.Sprintf("speed-limit-%s-enabled", direction)] = false
+			} else {
+				kbps, err := strconv.Atoi(params[1])
+				if err != nil || kbps <= 0 {
+					return fmt.Sprintf("not a valid speed limit: %s", params[1]), nil
+				}
+				arguments[fmt.Sprintf("speed-limit-%s", direction)] = kbps
+				arguments[fmt.Sprintf("speed-limit-%s-enabled", direction)] = true
+			}
+		default:
+			return fmt.Sprintf("not a valid speed command: %s", txt), nil
+		}
+
+		if err := client.SetSession(ctx, arguments); err != nil {
+			return fmt.Sprintf("Failed to change speed limits: %s", err), nil
+		}
+		result = fmt.Sprintf("Applied: %s\n\n", strings.Join(params, " "))
+	}
+
+	message = withInstanceName(config, instance, result+client.GetSpeed(ctx))
+
+	// inline keyboards for presets
+	preset := func(text string, params ...string) bot.InlineKeyboardButton {
+		return bot.NewInlineKeyboardButton(text).
+			SetCallbackData(fmt.Sprintf("%s %s", cmd, strings.Join(params, " ")))
+	}
+	keyboards = [][]bot.InlineKeyboardButton{
+		{
+			preset(consts.MessageTurtleOn, consts.ParamSpeedTurtle, consts.ParamOn),
+			preset(consts.MessageTurtleOff, consts.ParamSpeedTurtle, consts.ParamOff),
+		},
+		{
+			preset("↓ 1 MB/s", consts.ParamSpeedDown, "1000"),
+			preset("↓ 5 MB/s", consts.ParamSpeedDown, "5000"),
+			preset("↓ ∞", consts.ParamSpeedDown, consts.ParamOff),
+		},
+		{
+			preset("↑ 100 KB/s", consts.ParamSpeedUp, "100"),
+			preset("↑ 1 MB/s", consts.ParamSpeedUp, "1000"),
+			preset("↑ ∞", consts.ParamSpeedUp, consts.ParamOff),
+		},
+		{
+			bot.NewInlineKeyboardButton(consts.MessageRefresh).
+				SetCallbackData(cmd),
+		},
+	}
+
+	return message, keyboards
+}
+
+// generate inline keyboards for showing details of given torrents
+func torrentInfoKeyboards(
+	instance cfg.TransmissionInstance,
+	torrents []RPCResponseTorrent,
+) (keyboards [][]bot.InlineKeyboardButton) {
+	row := []bot.InlineKeyboardButton{}
+	for i, t := range torrents {
+		if i >= consts.MaxInlineKeyboardButtons {
+			break
+		}
+
+		row = append(row, bot.NewInlineKeyboardButton(fmt.Sprintf("ℹ️ %d", t.ID)).
+			SetCallbackData(fmt.Sprintf("%s %d", instanceCommand(instance, consts.CommandTransmissionInfo), t.ID)))
+		if len(row) >= consts.NumInlineKeyboardsPerRow {
+			keyboards = append(keyboards, row)
+			row = []bot.InlineKeyboardButton{}
+		}
+	}
+	if len(row) > 0 {
+		keyboards = append(keyboards, row)
+	}
+
+	return keyboards
+}
+
 // process incoming update from Telegram
 func processUpdate(
 	ctx context.Context,
@@ -352,23 +831,27 @@ func processUpdate(
 		}
 
 		var message string
+		var watching *watchTarget    // (target to watch with the sent message)
+		var document *documentToSend // (file to send instead of the message)
 		options := bot.OptionsSendMessage{}.
 			SetReplyMarkup(defaultReplyMarkup(true))
 
 		switch s.CurrentStatus {
 		case StatusWaiting:
 			if update.Message.Document != nil { // if a file is received,
-				// get file info
-				ctxFileInfo, cancelFileInfo := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
-				defer cancelFileInfo()
-				fileResult, _ := b.GetFile(ctxFileInfo, update.Message.Document.FileID)
-
-				fileURL := b.GetFileURL(*fileResult.Result)
-
 				// XXX - only support: .torrent
-				if strings.HasSuffix(fileURL, ".torrent") {
-					addReaction(ctx, b, update, "👌")
-					message = AddTorrent(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd, fileURL)
+				if isTorrentFile(*update.Message.Document) {
+					if metainfo, err := downloadTorrentFile(ctx, b, *update.Message.Document); err == nil {
+						addReaction(ctx, b, update, "👌")
+
+						var keyboards [][]bot.InlineKeyboardButton
+						message, keyboards = previewTorrent(ctx, config, userID, TorrentSource{Metainfo: metainfo})
+						if keyboards != nil {
+							options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+						}
+					} else {
+						message = fmt.Sprintf("Failed to read given torrent file: %s", err)
+					}
 				} else {
 					message = consts.MessageUnprocessableFileFormat
 				}
@@ -377,7 +860,12 @@ func processUpdate(
 				// magnet url
 				case strings.HasPrefix(txt, "magnet:"):
 					addReaction(ctx, b, update, "👌")
-					message = AddTorrent(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd, txt)
+
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = previewTorrent(ctx, config, userID, TorrentSource{Filename: txt})
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
 				// /start
 				case strings.HasPrefix(txt, consts.CommandStart):
 					message = consts.MessageDefault
@@ -399,11 +887,111 @@ func processUpdate(
 					}
 				// transmission
 				case strings.HasPrefix(txt, consts.CommandTransmissionList):
-					message = GetList(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd)
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = getTransmissionList(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionInfo):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionInfoCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionFiles):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionFilesCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionWatch):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards, watching = parseTransmissionWatchCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionQueue):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionQueueCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionVerify) || strings.HasPrefix(txt, consts.CommandTransmissionReannounce):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards, watching = parseTransmissionVerifyCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionLocation):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionLocationCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionTrackers):
+					var keyboards [][]bot.InlineKeyboardButton
+					var waitingURL string
+					message, keyboards, waitingURL = parseTransmissionTrackersCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+					if len(waitingURL) > 0 {
+						pool.Sessions[userID] = session{
+							UserID:        userID,
+							CurrentStatus: StatusWaitingTrackerURL,
+							Command:       waitingURL,
+						}
+						options.SetReplyMarkup(cancelReplyMarkup(true))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionStalled):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionStalledCommand(ctx, config, db, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionStats):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionStatsCommand(ctx, config, db, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandRSS):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseRSSCommand(config, db, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionExport):
+					var filename string
+					var data []byte
+					if filename, data, message = parseTransmissionExportCommand(ctx, config, txt); data != nil {
+						document = &documentToSend{Filename: filename, Data: data}
+					}
+				case strings.HasPrefix(txt, consts.CommandTransmissionImport):
+					message = consts.MessageTransmissionImport
+					pool.Sessions[userID] = session{
+						UserID:        userID,
+						CurrentStatus: StatusWaitingImportFile,
+						Command:       txt,
+					}
+					options.SetReplyMarkup(cancelReplyMarkup(true))
+				case strings.HasPrefix(txt, consts.CommandTransmissionClean):
+					params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionClean, "", 1)))
+					message, _, _ = cleanupTorrents(ctx, config, db, len(params) > 0 && params[0] == consts.ParamDryRun)
+				case strings.HasPrefix(txt, consts.CommandTransmissionSpeed):
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = parseTransmissionSpeedCommand(ctx, config, txt)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
 				case strings.HasPrefix(txt, consts.CommandTransmissionAdd):
 					arg := strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionAdd, "", 1))
 					if strings.HasPrefix(arg, "magnet:") {
-						message = AddTorrent(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd, arg)
+						var keyboards [][]bot.InlineKeyboardButton
+						message, keyboards = previewTorrent(ctx, config, userID, TorrentSource{Filename: arg})
+						if keyboards != nil {
+							options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+						}
 					} else {
 						message = consts.MessageTransmissionUpload
 						pool.Sessions[userID] = session{
@@ -412,9 +1000,12 @@ func processUpdate(
 						}
 						options.SetReplyMarkup(cancelReplyMarkup(true))
 					}
-				case strings.HasPrefix(txt, consts.CommandTransmissionRemove) || strings.HasPrefix(txt, consts.CommandTransmissionDelete):
+				case strings.HasPrefix(txt, consts.CommandTransmissionRemove) ||
+					strings.HasPrefix(txt, consts.CommandTransmissionDelete) ||
+					strings.HasPrefix(txt, consts.CommandTransmissionPause) ||
+					strings.HasPrefix(txt, consts.CommandTransmissionResume):
 					var keyboards [][]bot.InlineKeyboardButton
-					message, keyboards = parseTransmissionCommand(config, txt)
+					message, keyboards = parseTransmissionCommand(ctx, config, txt)
 					if keyboards != nil {
 						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
 					}
@@ -442,20 +1033,73 @@ func processUpdate(
 			case strings.HasPrefix(txt, consts.CommandCancel):
 				message = consts.MessageCanceled
 			default:
-				var torrent string
+				var torrent TorrentSource
+				var err error
 				if update.Message.Document != nil {
-					// get file info
-					ctxFileInfo, cancelFileInfo := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
-					defer cancelFileInfo()
-					fileResult, _ := b.GetFile(ctxFileInfo, update.Message.Document.FileID)
+					if !isTorrentFile(*update.Message.Document) {
+						err = errUnprocessableFileFormat
+					} else {
+						torrent.Metainfo, err = downloadTorrentFile(ctx, b, *update.Message.Document)
+					}
+				} else {
+					torrent.Filename = txt
+				}
 
-					torrent = b.GetFileURL(*fileResult.Result)
+				if errors.Is(err, errUnprocessableFileFormat) {
+					message = consts.MessageUnprocessableFileFormat
+				} else if err == nil {
+					addReaction(ctx, b, update, "👌")
+
+					var keyboards [][]bot.InlineKeyboardButton
+					message, keyboards = previewTorrent(ctx, config, userID, torrent)
+					if keyboards != nil {
+						options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+					}
 				} else {
-					torrent = txt
+					message = fmt.Sprintf("Failed to read given torrent file: %s", err)
+				}
+			}
+
+			// reset status
+			pool.Sessions[userID] = session{
+				UserID:        userID,
+				CurrentStatus: StatusWaiting,
+			}
+		case StatusWaitingTrackerURL:
+			switch {
+			case strings.HasPrefix(txt, consts.CommandCancel):
+				message = consts.MessageCanceled
+			default:
+				var keyboards [][]bot.InlineKeyboardButton
+				message, keyboards, _ = parseTransmissionTrackersCommand(ctx, config, fmt.Sprintf("%s %s", s.Command, strings.TrimSpace(txt)))
+				if keyboards != nil {
+					options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+				}
+			}
+
+			// reset status
+			pool.Sessions[userID] = session{
+				UserID:        userID,
+				CurrentStatus: StatusWaiting,
+			}
+		case StatusWaitingImportFile:
+			switch {
+			case strings.HasPrefix(txt, consts.CommandCancel):
+				message = consts.MessageCanceled
+			case update.Message.Document == nil:
+				message = consts.MessageUnprocessableFileFormat
+			default:
+				var filename string
+				if update.Message.Document.FileName != nil {
+					filename = *update.Message.Document.FileName
 				}
+				if data, err := downloadFile(ctx, b, *update.Message.Document, consts.MaxExportFileSize); err == nil {
+					addReaction(ctx, b, update, "👌")
 
-				addReaction(ctx, b, update, "👌")
-				message = AddTorrent(config.TransmissionRPCPort, config.TransmissionRPCUsername, config.TransmissionRPCPasswd, torrent)
+					message = importTorrents(ctx, config, db, s.Command, filename, data)
+				} else {
+					message = fmt.Sprintf("Failed to read given file: %s", err)
+				}
 			}
 
 			// reset status
@@ -466,34 +1110,15 @@ func processUpdate(
 		}
 
 		// send message
-		ctxSend, cancelSend := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
-		defer cancelSend()
-		if checkMarkdownValidity(message) {
-			options.SetParseMode(bot.ParseModeMarkdown)
-		}
-		if sent, err := b.SendMessage(
-			ctxSend,
-			update.Message.Chat.ID,
-			message,
-			options,
-		); sent.OK {
-			result = true
-		} else {
-			var errMessageEmpty bot.ErrMessageEmpty
-			var errMessageTooLong bot.ErrMessageTooLong
-			var errNoChatID bot.ErrChatNotFound
-			var errTooManyRequests bot.ErrTooManyRequests
-			if errors.As(err, &errMessageEmpty) {
-				logError(db, "message is empty")
-			} else if errors.As(err, &errMessageTooLong) {
-				logError(db, "message is too long: %d bytes", len(message))
-			} else if errors.As(err, &errNoChatID) {
-				logError(db, "no such chat id: %d", update.Message.Chat.ID)
-			} else if errors.As(err, &errTooManyRequests) {
-				logError(db, "too many requests")
-			} else {
-				logError(db, "failed to send message: %s", *sent.Description)
+		if document != nil {
+			result = sendDocument(ctx, b, db, update.Message.Chat.ID, *document, message)
+		} else if watching != nil {
+			var messageID int64
+			if messageID, result = sendMessageAndGetID(ctx, b, db, update.Message.Chat.ID, message, options); result {
+				startWatching(ctx, b, config, db, update.Message.Chat.ID, messageID, message, *watching)
 			}
+		} else {
+			result = sendMessage(ctx, b, db, update.Message.Chat.ID, message, options)
 		}
 	} else {
 		logError(db, "no session for id: %s", userID)
@@ -503,6 +1128,191 @@ func processUpdate(
 	return result
 }
 
+// check if given document is a .torrent file
+func isTorrentFile(document bot.Document) bool {
+	if document.MimeType != nil && *document.MimeType == consts.MimeTypeTorrent {
+		return true
+	}
+	return document.FileName != nil && strings.HasSuffix(strings.ToLower(*document.FileName), ".torrent")
+}
+
+// download given .torrent file from telegram and return its validated contents
+func downloadTorrentFile(
+	ctx context.Context,
+	b *bot.Bot,
+	document bot.Document,
+) (metainfo []byte, err error) {
+	if metainfo, err = downloadFile(ctx, b, document, consts.MaxTorrentFileSize); err != nil {
+		return nil, err
+	}
+
+	// validate
+	if _, err = parseMetainfo(metainfo); err != nil {
+		return nil, err
+	}
+
+	return metainfo, nil
+}
+
+// download given file from telegram and return its contents
+//
+// (file url contains the bot api token, so it should not be exposed anywhere)
+func downloadFile(
+	ctx context.Context,
+	b *bot.Bot,
+	document bot.Document,
+	maxSize int64,
+) (data []byte, err error) {
+	if int64(document.FileSize) > maxSize {
+		return nil, fmt.Errorf("file is too large: %d bytes", document.FileSize)
+	}
+
+	// get file info
+	ctxFileInfo, cancelFileInfo := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
+	defer cancelFileInfo()
+	fileResult, err := b.GetFile(ctxFileInfo, document.FileID)
+	if err != nil || !fileResult.OK || fileResult.Result == nil || fileResult.Result.FilePath == nil {
+		return nil, fmt.Errorf("failed to get file info")
+	}
+
+	// download file
+	ctxDownload, cancelDownload := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
+	defer cancelDownload()
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctxDownload, http.MethodGet, b.GetFileURL(*fileResult.Result), nil); err != nil {
+		return nil, fmt.Errorf("failed to build request")
+	}
+	var resp *http.Response
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		// strip url (with the bot api token) from the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to download file: %s", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: HTTP %d", resp.StatusCode)
+	}
+	if data, err = io.ReadAll(io.LimitReader(resp.Body, maxSize+1)); err != nil {
+		return nil, fmt.Errorf("failed to read file: %s", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is too large")
+	}
+
+	return data, nil
+}
+
+// send a message to given chat
+func sendMessage(
+	ctx context.Context,
+	b *bot.Bot,
+	db *Database,
+	chatID int64,
+	message string,
+	options bot.OptionsSendMessage,
+) bool {
+	_, sent := sendMessageAndGetID(ctx, b, db, chatID, message, options)
+
+	return sent
+}
+
+// send a message and return the id of the sent message
+func sendMessageAndGetID(
+	ctx context.Context,
+	b *bot.Bot,
+	db *Database,
+	chatID int64,
+	message string,
+	options bot.OptionsSendMessage,
+) (messageID int64, ok bool) {
+	ctxSend, cancelSend := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
+	defer cancelSend()
+	if checkMarkdownValidity(message) {
+		options.SetParseMode(bot.ParseModeMarkdown)
+	}
+	if sent, err := b.SendMessage(
+		ctxSend,
+		chatID,
+		message,
+		options,
+	); sent.OK {
+		return sent.Result.MessageID, true
+	} else {
+		var errMessageEmpty bot.ErrMessageEmpty
+		var errMessageTooLong bot.ErrMessageTooLong
+		var errNoChatID bot.ErrChatNotFound
+		var errTooManyRequests bot.ErrTooManyRequests
+		if errors.As(err, &errMessageEmpty) {
+			logError(db, "message is empty")
+		} else if errors.As(err, &errMessageTooLong) {
+			logError(db, "message is too long: %d bytes", len(message))
+		} else if errors.As(err, &errNoChatID) {
+			logError(db, "no such chat id: %d", chatID)
+		} else if errors.As(err, &errTooManyRequests) {
+			logError(db, "too many requests")
+		} else {
+			logError(db, "failed to send message: %s", *sent.Description)
+		}
+	}
+
+	return 0, false
+}
+
+// file to be sent as a document
+type documentToSend struct {
+	Filename string
+	Data     []byte
+}
+
+// send a file as a document to given chat, with the caption
+func sendDocument(
+	ctx context.Context,
+	b *bot.Bot,
+	db *Database,
+	chatID int64,
+	document documentToSend,
+	caption string,
+) bool {
+	// (file is written to a temporary directory for being sent with its name)
+	dir, err := os.MkdirTemp("", "export")
+	if err != nil {
+		logError(db, "failed to create temporary directory: %s", err)
+		return false
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, document.Filename)
+	if err := os.WriteFile(path, document.Data, 0o600); err != nil {
+		logError(db, "failed to write file for sending: %s", err)
+		return false
+	}
+
+	options := bot.OptionsSendDocument{}.
+		SetReplyMarkup(defaultReplyMarkup(true))
+	if len(caption) > 0 {
+		options = options.SetCaption(caption)
+		if checkMarkdownValidity(caption) {
+			options = options.SetParseMode(bot.ParseModeMarkdown)
+		}
+	}
+
+	ctxSend, cancelSend := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
+	defer cancelSend()
+	if sent, err := b.SendDocument(ctxSend, chatID, bot.InputFile{Filepath: &path}, options); !sent.OK {
+		if sent.Description != nil {
+			logError(db, "failed to send document: %s", *sent.Description)
+		} else {
+			logError(db, "failed to send document: %s", err)
+		}
+		return false
+	}
+
+	return true
+}
+
 // add reaction to a message
 func addReaction(
 	ctx context.Context,
@@ -538,12 +1348,65 @@ func processCallbackQuery(
 	result = false
 
 	var message string
+	var keyboards [][]bot.InlineKeyboardButton
+	var watching *watchTarget // (target to watch with the edited message)
+	sendAsNew := false
 	if strings.HasPrefix(txt, consts.CommandCancel) {
 		message = ""
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionInfo) { // transmission info
+		message, keyboards = parseTransmissionInfoCommand(ctx, config, txt)
+
+		// (details requested without a page number, eg. from the list, will be sent as a new message)
+		_, rest, _ := extractInstance(config, txt)
+		sendAsNew = len(strings.Fields(rest)) == 2
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionList) { // transmission list (pages)
+		message, keyboards = getTransmissionList(ctx, config, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionWatch) { // transmission watch (stop)
+		stopWatching(query.Message.Chat.ID, query.Message.MessageID)
+		message = consts.MessageWatchStopped
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionQueue) { // transmission queue
+		message, keyboards = parseTransmissionQueueCommand(ctx, config, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionVerify) || strings.HasPrefix(txt, consts.CommandTransmissionReannounce) { // transmission verify/reannounce
+		message, keyboards, watching = parseTransmissionVerifyCommand(ctx, config, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionLocation) { // transmission location
+		message, keyboards = parseTransmissionLocationCommand(ctx, config, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionTrackers) { // transmission trackers
+		var waitingURL string
+		message, keyboards, waitingURL = parseTransmissionTrackersCommand(ctx, config, txt)
+
+		// wait for the announce url from the user
+		if len(waitingURL) > 0 && query.From.Username != nil {
+			userID := *query.From.Username
+
+			pool.Lock()
+			if _, exists := pool.Sessions[userID]; exists {
+				pool.Sessions[userID] = session{
+					UserID:        userID,
+					CurrentStatus: StatusWaitingTrackerURL,
+					Command:       waitingURL,
+				}
+			}
+			pool.Unlock()
+		}
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionStalled) { // transmission stalled (refresh)
+		message, keyboards = parseTransmissionStalledCommand(ctx, config, db, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionStats) { // transmission stats
+		message, keyboards = parseTransmissionStatsCommand(ctx, config, db, txt)
+	} else if strings.HasPrefix(txt, consts.CommandRSS) { // rss feeds
+		message, keyboards = parseRSSCommand(config, db, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionFiles) { // transmission files
+		message, keyboards = parseTransmissionFilesCommand(ctx, config, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionAdd) { // transmission add (from preview)
+		message, keyboards = parseTransmissionAddCommand(ctx, b, config, db, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionSpeed) { // transmission speed
+		message, keyboards = parseTransmissionSpeedCommand(ctx, config, txt)
 	} else if strings.HasPrefix(txt, consts.CommandServiceStart) || strings.HasPrefix(txt, consts.CommandServiceStop) { // service
 		message, _ = parseServiceCommand(config, db, txt)
-	} else if strings.HasPrefix(txt, consts.CommandTransmissionRemove) || strings.HasPrefix(txt, consts.CommandTransmissionDelete) { // transmission
-		message, _ = parseTransmissionCommand(config, txt)
+	} else if strings.HasPrefix(txt, consts.CommandTransmissionRemove) ||
+		strings.HasPrefix(txt, consts.CommandTransmissionDelete) ||
+		strings.HasPrefix(txt, consts.CommandTransmissionPause) ||
+		strings.HasPrefix(txt, consts.CommandTransmissionResume) { // transmission
+		message, keyboards = parseTransmissionCommand(ctx, config, txt)
 	} else {
 		logError(db, "unprocessable callback query: %s", txt)
 
@@ -552,7 +1415,7 @@ func processCallbackQuery(
 
 	// answer callback query
 	options := bot.OptionsAnswerCallbackQuery{}
-	if len(message) > 0 {
+	if len(message) > 0 && keyboards == nil {
 		options.SetText(message)
 	}
 	ctxAnswer, cancelAnswer := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
@@ -562,18 +1425,39 @@ func processCallbackQuery(
 			message = consts.MessageCanceled
 		}
 
-		// edit message and remove inline keyboards
-		ctxEdit, cancelEdit := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
-		defer cancelEdit()
-		if apiResult, _ := b.EditMessageText(
-			ctxEdit,
-			message,
-			bot.OptionsEditMessageText{}.
-				SetIDs(query.Message.Chat.ID, query.Message.MessageID),
-		); apiResult.OK {
-			result = true
+		if sendAsNew {
+			// send a new message
+			options := bot.OptionsSendMessage{}
+			if keyboards != nil {
+				options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+			}
+			result = sendMessage(ctx, b, db, query.Message.Chat.ID, message, options)
 		} else {
-			logError(db, "failed to edit message text: %s", *apiResult.Description)
+			// edit message and replace (or remove) inline keyboards
+			options := bot.OptionsEditMessageText{}.
+				SetIDs(query.Message.Chat.ID, query.Message.MessageID)
+			if keyboards != nil {
+				options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+			}
+			if checkMarkdownValidity(message) {
+				options.SetParseMode(bot.ParseModeMarkdown)
+			}
+
+			ctxEdit, cancelEdit := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
+			defer cancelEdit()
+			if apiResult, _ := b.EditMessageText(
+				ctxEdit,
+				message,
+				options,
+			); apiResult.OK {
+				result = true
+
+				if watching != nil {
+					startWatching(ctx, b, config, db, query.Message.Chat.ID, query.Message.MessageID, message, *watching)
+				}
+			} else {
+				logError(db, "failed to edit message text: %s", *apiResult.Description)
+			}
 		}
 	} else {
 		logError(db, "failed to answer callback query: %+v", query)
@@ -589,11 +1473,26 @@ func broadcast(
 	config cfg.Config,
 	db *Database,
 	message string,
+) {
+	broadcastWithKeyboards(ctx, client, config, db, message, nil)
+}
+
+// broadcast a messge with inline keyboards to given chats
+func broadcastWithKeyboards(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+	message string,
+	keyboards [][]bot.InlineKeyboardButton,
 ) {
 	for _, chat := range db.GetChats() {
 		if isAvailableID(config, chat.UserID) {
 			options := bot.OptionsSendMessage{}.
 				SetReplyMarkup(defaultReplyMarkup(true))
+			if keyboards != nil {
+				options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+			}
 			if checkMarkdownValidity(message) {
 				options.SetParseMode(bot.ParseModeMarkdown)
 			}
@@ -734,6 +1633,32 @@ func runBot(
 				}
 			}()
 
+			// notify changes of torrents
+			if config.TorrentNotificationInterval > 0 {
+				go runTorrentNotifier(ctx, client, config, db)
+			}
+
+			// clean up seeded torrents
+			if config.Cleanup != nil && config.Cleanup.Interval > 0 {
+				go runTorrentCleaner(ctx, client, config, db)
+			}
+
+			// detect stalled torrents (progresses are recorded even when they are not notified)
+			go runStalledDetector(ctx, client, config, db)
+
+			// record transfer volumes
+			go runTransferStatsRecorder(ctx, config, db)
+
+			// download torrents from rss feeds
+			if config.RSS != nil {
+				go runRSSWatcher(ctx, client, config, db)
+			}
+
+			// add torrents saved in watch folders
+			if len(config.WatchFolders) > 0 {
+				go runFolderWatcher(ctx, client, config, db)
+			}
+
 			// start web server for CLI
 			go func(config cfg.Config) {
 				if config.CLIPort <= 0 {
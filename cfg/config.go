@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	// infisical
@@ -31,15 +36,25 @@ const (
 
 // Config struct for config file
 type Config struct {
-	AvailableIDs            []string `json:"available_ids"`
-	ControllableServices    []string `json:"controllable_services,omitempty"`
-	MountPoints             []string `json:"mount_points,omitempty"`
-	MonitorInterval         int      `json:"monitor_interval"`
-	TransmissionRPCPort     int      `json:"transmission_rpc_port,omitempty"`
-	TransmissionRPCUsername string   `json:"transmission_rpc_username,omitempty"`
-	TransmissionRPCPasswd   string   `json:"transmission_rpc_passwd,omitempty"`
-	CLIPort                 int      `json:"cli_port"`
-	IsVerbose               bool     `json:"is_verbose"`
+	AvailableIDs                []string                `json:"available_ids"`
+	ControllableServices        []string                `json:"controllable_services,omitempty"`
+	MountPoints                 []string                `json:"mount_points,omitempty"`
+	MonitorInterval             int                     `json:"monitor_interval"`
+	TorrentNotificationInterval int                     `json:"torrent_notification_interval,omitempty"`
+	TransmissionRPCPort         int                     `json:"transmission_rpc_port,omitempty"`     // (deprecated: use `transmission_rpc` instead)
+	TransmissionRPCUsername     string                  `json:"transmission_rpc_username,omitempty"` // (deprecated)
+	TransmissionRPCPasswd       string                  `json:"transmission_rpc_passwd,omitempty"`   // (deprecated)
+	TransmissionRPC             TransmissionRPCEndpoint `json:"transmission_rpc,omitzero"`
+	TransmissionInstances       []TransmissionInstance  `json:"transmission_instances,omitempty"`
+	DownloadLocations           []DownloadLocation      `json:"download_locations,omitempty"`
+	DefaultDownloadLocations    map[string]string       `json:"default_download_locations,omitempty"` // telegram id => name of download location
+	MaxDiskUsagePercent         int                     `json:"max_disk_usage_percent,omitempty"`
+	Cleanup                     *CleanupPolicy          `json:"cleanup,omitempty"`
+	Stalled                     *StalledPolicy          `json:"stalled,omitempty"`
+	RSS                         *RSSConfig              `json:"rss,omitempty"`
+	WatchFolders                []WatchFolder           `json:"watch_folders,omitempty"`
+	CLIPort                     int                     `json:"cli_port"`
+	IsVerbose                   bool                    `json:"is_verbose"`
 
 	// Bot API Token,
 	APIToken string `json:"api_token,omitempty"`
@@ -57,6 +72,201 @@ type Config struct {
 	} `json:"infisical,omitempty"`
 }
 
+// TransmissionRPCEndpoint struct for the RPC endpoint of a transmission (or other torrent) daemon
+type TransmissionRPCEndpoint struct {
+	Client        string `json:"client,omitempty"` // `transmission`, `qbittorrent`, or `aria2`
+	Scheme        string `json:"scheme,omitempty"` // `http` or `https`
+	Host          string `json:"host,omitempty"`
+	Port          int    `json:"port,omitempty"`
+	Path          string `json:"path,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Passwd        string `json:"passwd,omitempty"`          // (secret token for aria2)
+	CACertPath    string `json:"ca_cert_path,omitempty"`    // PEM file of CA certificates for verifying the server
+	SkipTLSVerify bool   `json:"skip_tls_verify,omitempty"` // skip verification of the server's certificate
+	Timeout       int    `json:"timeout,omitempty"`         // seconds
+	MaxRetries    int    `json:"max_retries,omitempty"`     // retries on network errors (negative for no retry)
+	RetryInterval int    `json:"retry_interval,omitempty"`  // milliseconds (doubled on each retry)
+}
+
+// URL returns the RPC url of this endpoint (without credentials).
+func (e TransmissionRPCEndpoint) URL() string {
+	return (&url.URL{
+		Scheme: e.Scheme,
+		Host:   net.JoinHostPort(e.Host, strconv.Itoa(e.Port)),
+		Path:   e.Path,
+	}).String()
+}
+
+// fill empty values of this endpoint with the deprecated fields and default values
+func (e *TransmissionRPCEndpoint) fillDefaults(port int, username, passwd string) {
+	if len(e.Client) <= 0 {
+		e.Client = consts.TorrentClientTransmission
+	}
+	if len(e.Scheme) <= 0 {
+		e.Scheme = consts.DefaultTransmissionRPCScheme
+	}
+	if len(e.Host) <= 0 {
+		e.Host = consts.DefaultTransmissionRPCHost
+	}
+	if e.Port <= 0 {
+		e.Port = port
+	}
+	if e.Port <= 0 || len(e.Path) <= 0 {
+		defaultPort, defaultPath := consts.DefaultTransmissionRPCPort, consts.DefaultTransmissionRPCPath
+		switch e.Client {
+		case consts.TorrentClientQBittorrent:
+			defaultPort, defaultPath = consts.DefaultQBittorrentPort, consts.DefaultQBittorrentAPIPath
+		case consts.TorrentClientAria2:
+			defaultPort, defaultPath = consts.DefaultAria2RPCPort, consts.DefaultAria2RPCPath
+		}
+
+		if e.Port <= 0 {
+			e.Port = defaultPort
+		}
+		if len(e.Path) <= 0 {
+			e.Path = defaultPath
+		}
+	}
+	if len(e.Username) <= 0 && len(e.Passwd) <= 0 {
+		e.Username, e.Passwd = username, passwd
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = consts.DefaultTransmissionRPCTimeoutSeconds
+	}
+	if e.MaxRetries == 0 {
+		e.MaxRetries = consts.DefaultTransmissionRPCMaxRetries
+	}
+	if e.RetryInterval <= 0 {
+		e.RetryInterval = consts.DefaultTransmissionRPCRetryIntervalMsecs
+	}
+}
+
+// TransmissionInstance struct for a named transmission daemon
+type TransmissionInstance struct {
+	Name string `json:"name"`
+
+	TransmissionRPCEndpoint
+}
+
+// TransmissionInstance returns the transmission instance with given name.
+func (c Config) TransmissionInstance(name string) (instance TransmissionInstance, exists bool) {
+	for _, instance := range c.TransmissionInstances {
+		if instance.Name == name {
+			return instance, true
+		}
+	}
+	return instance, false
+}
+
+// validate values of this instance
+func (i TransmissionInstance) validate() error {
+	// (name will be used in commands and callback data)
+	if len(i.Name) <= 0 || len(i.Name) > consts.MaxTransmissionInstanceNameLength || strings.ContainsAny(i.Name, " \t\n@") {
+		return fmt.Errorf("not a valid name of transmission instance: '%s'", i.Name)
+	}
+
+	switch i.Client {
+	case consts.TorrentClientTransmission, consts.TorrentClientQBittorrent, consts.TorrentClientAria2:
+	default:
+		return fmt.Errorf("unsupported `client` of transmission instance '%s': %s", i.Name, i.Client)
+	}
+
+	switch i.Scheme {
+	case "http", "https":
+	default:
+		return fmt.Errorf("unsupported `scheme` of transmission instance '%s': %s", i.Name, i.Scheme)
+	}
+
+	return nil
+}
+
+// DownloadLocation struct for a named download directory
+type DownloadLocation struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// CleanupPolicy struct for removing seeded torrents automatically
+//
+// (torrents which are completed and match any of the conditions will be removed)
+type CleanupPolicy struct {
+	Interval        int     `json:"interval,omitempty"`         // seconds (0 for manual cleanup only)
+	SeedRatio       float64 `json:"seed_ratio,omitempty"`       // upload ratio reached
+	SeedingDays     int     `json:"seeding_days,omitempty"`     // seeding longer than N days
+	CompletedBefore string  `json:"completed_before,omitempty"` // completed before this date (YYYY-MM-DD)
+	DeleteData      bool    `json:"delete_data,omitempty"`      // delete local data too
+}
+
+// StalledPolicy struct for detecting stalled torrents
+//
+// (downloading torrents whose progress has not advanced for the duration will be considered stalled)
+type StalledPolicy struct {
+	Interval int `json:"interval,omitempty"` // seconds (0 for no notification)
+	Hours    int `json:"hours,omitempty"`    // progress not advanced for N hours
+}
+
+// StalledHours returns the duration (in hours) for considering torrents stalled.
+func (c Config) StalledHours() int {
+	if c.Stalled != nil && c.Stalled.Hours > 0 {
+		return c.Stalled.Hours
+	}
+	return consts.DefaultStalledHours
+}
+
+// RSSConfig struct for downloading torrents from RSS/Atom feeds
+type RSSConfig struct {
+	Interval int       `json:"interval,omitempty"` // seconds
+	Feeds    []RSSFeed `json:"feeds,omitempty"`    // (will be added to the local database on launch)
+}
+
+// RSSFeed struct for a RSS/Atom feed
+type RSSFeed struct {
+	URL         string `json:"url"`
+	Include     string `json:"include,omitempty"`      // regular expression for titles of items to download
+	Exclude     string `json:"exclude,omitempty"`      // regular expression for titles of items not to download
+	DownloadDir string `json:"download_dir,omitempty"` // (transmission's default download directory if empty)
+	Instance    string `json:"instance,omitempty"`     // name of transmission instance (first one if empty)
+}
+
+// validate values of this feed
+func (f RSSFeed) validate(c Config) error {
+	if u, err := url.Parse(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("not a valid url of rss feed: '%s'", f.URL)
+	}
+	if _, err := regexp.Compile(f.Include); err != nil {
+		return fmt.Errorf("failed to compile `include` of rss feed '%s': %s", f.URL, err)
+	}
+	if _, err := regexp.Compile(f.Exclude); err != nil {
+		return fmt.Errorf("failed to compile `exclude` of rss feed '%s': %s", f.URL, err)
+	}
+	if len(f.Instance) > 0 {
+		if _, exists := c.TransmissionInstance(f.Instance); !exists {
+			return fmt.Errorf("no such transmission instance for rss feed '%s': %s", f.URL, f.Instance)
+		}
+	}
+	return nil
+}
+
+// WatchFolder struct for adding torrents from files saved in a directory
+type WatchFolder struct {
+	Path        string `json:"path"`
+	DownloadDir string `json:"download_dir,omitempty"` // (transmission's default download directory if empty)
+	Instance    string `json:"instance,omitempty"`     // name of transmission instance (first one if empty)
+}
+
+// validate values of this watch folder
+func (f WatchFolder) validate(c Config) error {
+	if !filepath.IsAbs(f.Path) {
+		return fmt.Errorf("not an absolute path of watch folder: '%s'", f.Path)
+	}
+	if len(f.Instance) > 0 {
+		if _, exists := c.TransmissionInstance(f.Instance); !exists {
+			return fmt.Errorf("no such transmission instance for watch folder '%s': %s", f.Path, f.Instance)
+		}
+	}
+	return nil
+}
+
 // GetConfigDir returns the config file's directory.
 func GetConfigDir() (configDir string, err error) {
 	// https://xdgbasedirectoryspecification.com
@@ -120,13 +330,61 @@ func GetConfig(ctx context.Context) (conf Config, err error) {
 						}
 					}
 
+					// (`transmission_rpc` would be silently ignored with `transmission_instances`)
+					if len(conf.TransmissionInstances) > 0 &&
+						(conf.TransmissionRPC != (TransmissionRPCEndpoint{}) || conf.TransmissionRPCPort != 0 || conf.TransmissionRPCUsername != "" || conf.TransmissionRPCPasswd != "") {
+						return Config{}, fmt.Errorf("`transmission_rpc` (or deprecated `transmission_rpc_*`) cannot be used along with `transmission_instances`")
+					}
+
 					// fallback values
-					if conf.TransmissionRPCPort <= 0 {
-						conf.TransmissionRPCPort = consts.DefaultTransmissionRPCPort
+					conf.TransmissionRPC.fillDefaults(conf.TransmissionRPCPort, conf.TransmissionRPCUsername, conf.TransmissionRPCPasswd)
+					if len(conf.TransmissionInstances) <= 0 {
+						conf.TransmissionInstances = []TransmissionInstance{
+							{
+								Name:                    consts.DefaultTransmissionInstanceName,
+								TransmissionRPCEndpoint: conf.TransmissionRPC,
+							},
+						}
+					} else {
+						for i := range conf.TransmissionInstances {
+							conf.TransmissionInstances[i].fillDefaults(0, "", "")
+						}
 					}
 					if conf.MonitorInterval <= 0 {
 						conf.MonitorInterval = consts.DefaultMonitorIntervalSeconds
 					}
+					if conf.RSS != nil && conf.RSS.Interval <= 0 {
+						conf.RSS.Interval = consts.DefaultRSSIntervalSeconds
+					}
+
+					// validate values
+					names := map[string]bool{}
+					for _, instance := range conf.TransmissionInstances {
+						if err = instance.validate(); err != nil {
+							return Config{}, err
+						}
+						if names[instance.Name] {
+							return Config{}, fmt.Errorf("duplicated name of transmission instance: %s", instance.Name)
+						}
+						names[instance.Name] = true
+					}
+					if conf.RSS != nil {
+						for _, feed := range conf.RSS.Feeds {
+							if err = feed.validate(conf); err != nil {
+								return Config{}, err
+							}
+						}
+					}
+					for _, folder := range conf.WatchFolders {
+						if err = folder.validate(conf); err != nil {
+							return Config{}, err
+						}
+					}
+					if conf.Cleanup != nil && len(conf.Cleanup.CompletedBefore) > 0 {
+						if _, err = time.Parse(consts.DateFormat, conf.Cleanup.CompletedBefore); err != nil {
+							return Config{}, fmt.Errorf("failed to parse `completed_before` of cleanup: %s", err)
+						}
+					}
 
 					return conf, err
 				}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// run a loop which periodically removes torrents matching the cleanup policy
+func runTorrentCleaner(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+) {
+	_stdout.Printf("starting torrent cleaner with interval: %d second(s)", config.Cleanup.Interval)
+
+	ticker := time.NewTicker(time.Duration(config.Cleanup.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if message, removed, failed := cleanupTorrents(ctx, config, db, false); removed > 0 || failed > 0 {
+				broadcast(ctx, client, config, db, message)
+			}
+		}
+	}
+}
+
+// remove torrents matching the cleanup policy (or just list them when `dryRun` is true)
+// and return the resulting message with the numbers of removed and failed torrents
+func cleanupTorrents(
+	ctx context.Context,
+	config cfg.Config,
+	db *Database,
+	dryRun bool,
+) (message string, numRemoved, numFailed int) {
+	if config.Cleanup == nil {
+		return consts.MessageNoCleanupPolicy, 0, 0
+	}
+
+	lines, removed, failed := []string{}, []string{}, []string{}
+	for _, instance := range config.TransmissionInstances {
+		client := torrentClientFor(instance)
+
+		var torrents []RPCResponseTorrent
+		var err error
+		if tc, ok := transmissionClientFor(instance); ok {
+			torrents, err = tc.getTorrentsWithFields(ctx, torrentCleanupFields)
+		} else {
+			torrents, err = client.GetTorrents(ctx)
+		}
+		if err != nil {
+			logError(db, "failed to get torrents of '%s' for cleanup: %s", instance.Name, err)
+
+			lines = append(lines, withInstanceName(config, instance, fmt.Sprintf("Failed to get torrents for cleanup: %s", err)))
+			continue
+		}
+
+		if dryRun && instance.Client == consts.TorrentClientAria2 && (config.Cleanup.SeedingDays > 0 || len(config.Cleanup.CompletedBefore) > 0) {
+			lines = append(lines, withInstanceName(config, instance, "(aria2 reports neither seeding time nor completion date, so only seed ratio is checked)"))
+		}
+
+		for _, t := range torrents {
+			reason := cleanupReason(*config.Cleanup, t)
+			if len(reason) <= 0 {
+				continue
+			}
+
+			if dryRun {
+				lines = append(lines, withInstanceName(config, instance, fmt.Sprintf("*%d*. _%s_\n  ┖ %s", t.ID, removeMarkdownChars(t.Name, " "), reason)))
+			} else {
+				result, ok := client.RemoveTorrent(ctx, strconv.Itoa(t.ID), config.Cleanup.DeleteData)
+				line := withInstanceName(config, instance, fmt.Sprintf("*%d*. _%s_\n  ┖ %s\n  ┖ %s", t.ID, removeMarkdownChars(t.Name, " "), reason, removeMarkdownChars(result, " ")))
+				if ok {
+					removed = append(removed, line)
+				} else {
+					failed = append(failed, line)
+				}
+			}
+		}
+	}
+	numRemoved, numFailed = len(removed), len(failed)
+
+	if dryRun {
+		if len(lines) <= 0 {
+			return consts.MessageNoTorrentsToCleanup, 0, 0
+		}
+		return strings.Join(append([]string{"*torrents to be removed*"}, lines...), "\n"), 0, 0
+	}
+
+	if numRemoved <= 0 && numFailed <= 0 {
+		if len(lines) <= 0 {
+			return consts.MessageNoTorrentsToCleanup, 0, 0
+		}
+		return strings.Join(lines, "\n"), 0, 0
+	}
+	if numRemoved > 0 {
+		lines = append(lines, "*removed torrents*")
+		lines = append(lines, removed...)
+
+		db.Log(fmt.Sprintf("cleaned up %d torrent(s)", numRemoved))
+	}
+	if numFailed > 0 {
+		lines = append(lines, "*failed to remove*")
+		lines = append(lines, failed...)
+
+		logError(db, "failed to clean up %d torrent(s)", numFailed)
+	}
+
+	return strings.Join(lines, "\n"), numRemoved, numFailed
+}
+
+// check if given torrent matches the cleanup policy, and return the reason if so
+func cleanupReason(
+	policy cfg.CleanupPolicy,
+	torrent RPCResponseTorrent,
+) string {
+	// only completed torrents
+	if torrent.PercentDone < 1.0 {
+		return ""
+	}
+
+	if policy.SeedRatio > 0 && torrent.UploadRatio >= policy.SeedRatio {
+		return fmt.Sprintf("seed ratio %.2f reached %.2f", torrent.UploadRatio, policy.SeedRatio)
+	}
+	if policy.SeedingDays > 0 && torrent.SecondsSeeding >= int64(policy.SeedingDays)*24*60*60 {
+		return fmt.Sprintf("seeding for %d day(s)", torrent.SecondsSeeding/(24*60*60))
+	}
+	if len(policy.CompletedBefore) > 0 && torrent.DoneDate > 0 {
+		if before, err := time.ParseInLocation(consts.DateFormat, policy.CompletedBefore, time.Local); err == nil {
+			if done := time.Unix(torrent.DoneDate, 0); done.Before(before) {
+				return fmt.Sprintf("completed at %s", done.Format(consts.DateFormat))
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// TorrentClient is an interface for controlling torrent daemons
+//
+// (torrents of all daemons are represented as `RPCResponseTorrent`s of transmission)
+type TorrentClient interface {
+	// GetTorrents retrieves all torrents.
+	GetTorrents(ctx context.Context) ([]RPCResponseTorrent, error)
+
+	// GetTorrent retrieves a torrent with its details (files, peers, and trackers).
+	GetTorrent(ctx context.Context, torrentID string) (RPCResponseTorrent, error)
+
+	// AddTorrent adds a torrent and returns the resulting string,
+	// along with the added torrent (if known) and whether it was added.
+	AddTorrent(ctx context.Context, torrent TorrentSource, downloadDir string, paused bool) (string, *RPCResponseTorrent, bool)
+
+	// RemoveTorrent removes a torrent (and its local data when `deleteLocal` is true),
+	// and returns the resulting string, along with whether it was removed.
+	RemoveTorrent(ctx context.Context, torrentID string, deleteLocal bool) (string, bool)
+
+	// PauseTorrent stops a torrent (or all torrents with "all").
+	PauseTorrent(ctx context.Context, torrentID string) string
+
+	// ResumeTorrent starts a torrent (or all torrents with "all").
+	ResumeTorrent(ctx context.Context, torrentID string, now bool) string
+}
+
+// torrent clients for each endpoint
+var (
+	torrentClients      = map[cfg.TransmissionRPCEndpoint]TorrentClient{}
+	torrentClientsMutex sync.Mutex
+)
+
+// get (or create) a torrent client for given instance
+func torrentClientFor(instance cfg.TransmissionInstance) TorrentClient {
+	torrentClientsMutex.Lock()
+	defer torrentClientsMutex.Unlock()
+
+	rpc := instance.TransmissionRPCEndpoint
+	if client, exists := torrentClients[rpc]; exists {
+		return client
+	}
+
+	var client TorrentClient
+	switch rpc.Client {
+	case consts.TorrentClientQBittorrent:
+		client = newQBittorrentClient(rpc)
+	case consts.TorrentClientAria2:
+		client = newAria2Client(rpc)
+	default:
+		client = newTransmissionClient(rpc)
+	}
+	torrentClients[rpc] = client
+
+	return client
+}
+
+// get the transmission client for given instance
+//
+// (returns false if given instance is not a transmission daemon)
+func transmissionClientFor(instance cfg.TransmissionInstance) (*transmissionClient, bool) {
+	client, ok := torrentClientFor(instance).(*transmissionClient)
+	return client, ok
+}
+
+// check if given instance is a transmission daemon
+//
+// (some features like file selection and speed limits are only available for transmission)
+func isTransmission(instance cfg.TransmissionInstance) bool {
+	return instance.Client == consts.TorrentClientTransmission
+}
+
+// create a http client for given RPC endpoint (with TLS options and timeout)
+func newHTTPClient(rpc cfg.TransmissionRPCEndpoint) (client *http.Client, err error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: rpc.SkipTLSVerify,
+	}
+	if len(rpc.CACertPath) > 0 {
+		var pem []byte
+		if pem, err = os.ReadFile(rpc.CACertPath); err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %s", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid CA certificates in: %s", rpc.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(rpc.Timeout) * time.Second,
+	}, nil
+}
+
+// mapping between string keys (eg. info hashes, gids) of torrents and numeric ids
+//
+// (for daemons without numeric torrent ids; ids are kept only while running)
+type torrentIDMap struct {
+	ids    map[string]int
+	keys   map[int]string
+	lastID int
+	sync.Mutex
+}
+
+// create a new id map
+func newTorrentIDMap() *torrentIDMap {
+	return &torrentIDMap{
+		ids:  map[string]int{},
+		keys: map[int]string{},
+	}
+}
+
+// get (or assign) the numeric id of given key
+func (m *torrentIDMap) id(key string) int {
+	m.Lock()
+	defer m.Unlock()
+
+	if id, exists := m.ids[key]; exists {
+		return id
+	}
+
+	m.lastID++
+	m.ids[key] = m.lastID
+	m.keys[m.lastID] = key
+
+	return m.lastID
+}
+
+// make the numeric id of given key point to the key which follows it
+//
+// (eg. gid of a torrent which was started by the metadata download of a magnet link in aria2)
+func (m *torrentIDMap) follow(key, followedBy string) {
+	m.Lock()
+	defer m.Unlock()
+
+	id, exists := m.ids[key]
+	if !exists {
+		return
+	}
+	if _, exists := m.ids[followedBy]; exists {
+		return
+	}
+
+	delete(m.ids, key)
+	m.ids[followedBy] = id
+	m.keys[id] = followedBy
+}
+
+// get the key of given numeric id
+func (m *torrentIDMap) key(torrentID string) (string, error) {
+	id, err := strconv.Atoi(torrentID)
+	if err != nil {
+		return "", fmt.Errorf("not a valid torrent id: %s", torrentID)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if key, exists := m.keys[id]; exists {
+		return key, nil
+	}
+	return "", fmt.Errorf("no such torrent: %s", torrentID)
+}
+
+// generate a result message of removing a torrent
+func removedMessage(torrentID string, deleteLocal bool) string {
+	if deleteLocal {
+		return fmt.Sprintf("Torrent id: %s and its data were successfully deleted", torrentID)
+	}
+	return fmt.Sprintf("Torrent id: %s was successfully removed from the list", torrentID)
+}
+
+// generate a result message of pausing torrent(s)
+func pausedMessage(torrentID string) string {
+	if torrentID == consts.ParamAllTorrents {
+		return "All torrents were successfully paused"
+	}
+	return fmt.Sprintf("Torrent id: %s was successfully paused", torrentID)
+}
+
+// generate a result message of resuming torrent(s)
+func resumedMessage(torrentID string) string {
+	if torrentID == consts.ParamAllTorrents {
+		return "All torrents were successfully resumed"
+	}
+	return fmt.Sprintf("Torrent id: %s was successfully resumed", torrentID)
+}
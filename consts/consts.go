@@ -11,11 +11,44 @@ const (
 	QueueSize            = 3
 
 	// for Transmission daemon
-	DefaultTransmissionRPCPort = 9091
+	DefaultTransmissionRPCScheme             = `http`
+	DefaultTransmissionRPCHost               = `localhost`
+	DefaultTransmissionRPCPort               = 9091
+	DefaultTransmissionRPCPath               = `/transmission/rpc`
+	DefaultTransmissionRPCTimeoutSeconds     = 30
+	DefaultTransmissionRPCMaxRetries         = 3
+	DefaultTransmissionRPCRetryIntervalMsecs = 500
+	DefaultTransmissionInstanceName          = `default`
+	MaxTransmissionInstanceNameLength        = 16
+
+	// for other torrent daemons
+	TorrentClientTransmission = `transmission`
+	TorrentClientQBittorrent  = `qbittorrent`
+	TorrentClientAria2        = `aria2`
+	DefaultQBittorrentPort    = 8080
+	DefaultQBittorrentAPIPath = `/api/v2`
+	DefaultAria2RPCPort       = 6800
+	DefaultAria2RPCPath       = `/jsonrpc`
+	Aria2PageSize             = 1000 // number of waiting/stopped downloads to query at once
 
 	// for monitoring
 	DefaultMonitorIntervalSeconds = 3
 
+	// for watch folders
+	WatchFolderDoneDir            = `done`
+	WatchFolderFailedDir          = `failed`
+	WatchFolderSettleMilliseconds = 1000 // wait for files to be completely written
+	MaxMagnetFileSize             = 64 * 1024
+
+	// for rss feeds
+	DefaultRSSIntervalSeconds = 900
+	RSSFetchTimeoutSeconds    = 30
+	MaxRSSFeedSize            = 5 * 1024 * 1024 // 5MB
+	MaxRSSItemAttempts        = 3               // items which failed to be added will be retried on next polls
+
+	// for recording transfer volumes
+	TransferStatsIntervalSeconds = 300
+
 	// commands
 	CommandStart   = `/start`
 	CommandStatus  = `/status`
@@ -30,26 +63,254 @@ const (
 	CommandServiceStop   = `/servicestop`
 
 	// commands for transmission
-	CommandTransmissionList   = `/trlist`
-	CommandTransmissionAdd    = `/tradd`
-	CommandTransmissionRemove = `/trremove`
-	CommandTransmissionDelete = `/trdelete`
+	CommandTransmissionList       = `/trlist`
+	CommandTransmissionAdd        = `/tradd`
+	CommandTransmissionRemove     = `/trremove`
+	CommandTransmissionDelete     = `/trdelete`
+	CommandTransmissionPause      = `/trpause`
+	CommandTransmissionResume     = `/trresume`
+	CommandTransmissionInfo       = `/trinfo`
+	CommandTransmissionFiles      = `/trfiles`
+	CommandTransmissionSpeed      = `/trspeed`
+	CommandTransmissionClean      = `/trcleanup`
+	CommandTransmissionWatch      = `/trwatch`
+	CommandTransmissionQueue      = `/trqueue`
+	CommandTransmissionVerify     = `/trverify`
+	CommandTransmissionReannounce = `/trreannounce`
+	CommandTransmissionLocation   = `/trlocation`
+	CommandTransmissionTrackers   = `/trtrackers`
+	CommandTransmissionStalled    = `/trstalled`
+	CommandTransmissionExport     = `/trexport`
+	CommandTransmissionImport     = `/trimport`
+	CommandTransmissionStats      = `/trstats`
+
+	// commands for rss feeds
+	CommandRSS = `/rss`
+
+	// parameters for transmission commands
+	ParamAllTorrents = `all`
+	ParamResumeNow   = `now`
+
+	// parameters for transmission add command
+	ParamAdd             = `add`
+	ParamAddPaused       = `paused`
+	ParamChooseFolder    = `folder`
+	ParamDefaultLocation = `default`
+	ParamCancel          = `cancel`
+	ParamForce           = `force`
+
+	// parameters for transmission files command
+	ParamFilesToggleWanted  = `w`
+	ParamFilesCyclePriority = `p`
+	ParamFilesDone          = `d`
+
+	// parameters for transmission cleanup command
+	ParamDryRun = `dry-run`
+
+	// parameters for transmission list command
+	ParamListStatusDownloading = `downloading`
+	ParamListStatusSeeding     = `seeding`
+	ParamListStatusStopped     = `stopped`
+	ParamListStatusError       = `error`
+	ParamListSortPrefix        = `sort:`
+	ParamListSortName          = `name`
+	ParamListSortSize          = `size`
+	ParamListSortProgress      = `progress`
+	ParamListSortRate          = `rate`
+	ParamListSortAdded         = `added`
+	ParamListPagePrefix        = `p:`
+	ParamListQueryPrefix       = `q:`
+
+	// parameters for transmission watch command
+	ParamStopWatching = `stop`
+
+	// parameters for transmission queue command
+	ParamQueueTop    = `top`
+	ParamQueueUp     = `up`
+	ParamQueueDown   = `down`
+	ParamQueueBottom = `bottom`
+	ParamQueueSize   = `size`
+
+	// parameters for transmission verify/reannounce/location commands
+	ParamSelectionPrefix = `#`
+	ParamLocationMove    = `move`
+	ParamLocationSet     = `set`
+
+	// parameters for transmission trackers command
+	ParamTrackerAdd     = `add`
+	ParamTrackerRemove  = `remove`
+	ParamTrackerReplace = `replace`
+
+	// parameters for transmission export command
+	ParamExportCSV = `csv`
+
+	// parameters for transmission stats command
+	ParamStatsDaily   = `daily`
+	ParamStatsMonthly = `monthly`
+
+	// parameters for rss command
+	ParamRSSList           = `list`
+	ParamRSSAdd            = `add`
+	ParamRSSRemove         = `remove`
+	ParamRSSPause          = `pause`
+	ParamRSSResume         = `resume`
+	ParamRSSIncludePrefix  = `include:`
+	ParamRSSExcludePrefix  = `exclude:`
+	ParamRSSLocationPrefix = `dir:`
+
+	// parameters for transmission speed command
+	ParamSpeedTurtle = `turtle`
+	ParamSpeedDown   = `down`
+	ParamSpeedUp     = `up`
+	ParamOn          = `on`
+	ParamOff         = `off`
 
 	// messages
-	MessageDefault                 = `Input your command:`
-	MessageUnknownCommand          = `Unknown command.`
-	MessageUnprocessableFileFormat = `Unprocessable file format.`
-	MessageNoControllableServices  = `No controllable services.`
-	MessageNoLogs                  = `No saved logs.`
-	MessageServiceToStart          = `Select service to start:`
-	MessageServiceToStop           = `Select service to stop:`
-	MessageTransmissionUpload      = `Send magnet, url, or file of target torrent:`
-	MessageTransmissionRemove      = `Send the id of torrent to remove from the list:`
-	MessageTransmissionDelete      = `Send the id of torrent to delete from the list and local storage:`
-	MessageTransmissionNoTorrents  = `No torrents.`
-	MessageCancel                  = `Cancel`
-	MessageCanceled                = `Canceled.`
+	MessageDefault                      = `Input your command:`
+	MessageUnknownCommand               = `Unknown command.`
+	MessageUnprocessableFileFormat      = `Unprocessable file format.`
+	MessageNoControllableServices       = `No controllable services.`
+	MessageNoLogs                       = `No saved logs.`
+	MessageServiceToStart               = `Select service to start:`
+	MessageServiceToStop                = `Select service to stop:`
+	MessageTransmissionUpload           = `Send magnet, url, or file of target torrent:`
+	MessageTransmissionRemove           = `Send the id of torrent to remove from the list:`
+	MessageTransmissionDelete           = `Send the id of torrent to delete from the list and local storage:`
+	MessageTransmissionPause            = `Send the id of torrent to pause:`
+	MessageTransmissionResume           = `Send the id of torrent to resume:`
+	MessageTransmissionInfo             = `Send the id of torrent to show details:`
+	MessageTransmissionFiles            = `Send the id of torrent to select files:`
+	MessageTransmissionFilesButton      = `📂 Files`
+	MessageTransmissionNoFiles          = `No files (yet).`
+	MessageTransmissionNoTorrents       = `No torrents.`
+	MessageTransmissionLocation         = `Select download location of the torrent:`
+	MessageTransmissionInstance         = `Select transmission instance:`
+	MessageNotSupportedByClient         = `Not supported by the torrent client of this instance.`
+	MessageTransmissionExpired          = `Given torrent is expired, send it again.`
+	MessageDefaultLocation              = `Default`
+	MessageAdd                          = `Add`
+	MessageAddPaused                    = `Add paused`
+	MessageChooseFolder                 = `Choose folder`
+	MessageUnknownName                  = `(unknown name)`
+	MessageAddAnyway                    = `Add anyway`
+	MessageStartAnyway                  = `Start anyway`
+	MessageRemove                       = `Remove`
+	MessageTransmissionStopped          = `(Torrent was stopped.)`
+	MessageTransmissionSizeUnknown      = `⚠️ size of torrent is unknown (metadata was not fetched in time), so disk usage was not checked`
+	MessageAllTorrents                  = `All torrents`
+	MessageCancel                       = `Cancel`
+	MessageCanceled                     = `Canceled.`
+	MessagePrevPage                     = `◀ Prev`
+	MessageNextPage                     = `Next ▶`
+	MessageRefresh                      = `🔄 Refresh`
+	MessageDone                         = `Done`
+	MessageTurtleOn                     = `🐢 Turtle on`
+	MessageTurtleOff                    = `🐇 Turtle off`
+	MessageNoCleanupPolicy              = `No cleanup policy configured.`
+	MessageNoTorrentsToCleanup          = `No torrents to clean up.`
+	MessageTransmissionNoActiveTorrents = `No active torrents.`
+	MessageStopWatching                 = `⏹ Stop watching`
+	MessageWatchStopped                 = `Stopped watching.`
+	MessageWatchFinished                = `✅ All active torrents finished.`
+	MessageQueueTop                     = `⏫ Top`
+	MessageQueueUp                      = `🔼 Up`
+	MessageQueueDown                    = `🔽 Down`
+	MessageQueueBottom                  = `⏬ Bottom`
+	MessageQueueShow                    = `📋 Queue`
+	MessageQueueOff                     = `Off`
+	MessageVerificationFinished         = `✅ Verification finished.`
+	MessageTransmissionVerify           = `Send the id of torrent to verify:`
+	MessageTransmissionReannounce       = `Send the id of torrent to reannounce:`
+	MessageTransmissionLocationTorrent  = `Send the id of torrent to change its location:`
+	MessageNoDownloadLocations          = `No download locations configured.`
+	MessageSelectionExpired             = `Selected torrents are expired, select them again.`
+	MessageListQueryExpired             = `Torrent list is expired, list torrents again.`
+	MessageLocationMove                 = `📦 Move to`
+	MessageLocationSet                  = `📍 Set to`
+	MessageVerify                       = `🔍 Verify`
+	MessageWatchTimedOut                = `(Stopped watching: timed out.)`
+	MessageTransmissionTrackers         = `Send the id of torrent to manage its trackers:`
+	MessageTransmissionTrackerURL       = `Send the announce url of tracker (or /cancel):`
+	MessageTransmissionNoTrackers       = `No trackers.`
+	MessageTrackerAdd                   = `➕ Add tracker`
+	MessageTrackerRemove                = `❌`
+	MessageTrackerReplace               = `🔁 Replace all`
+	MessageNoStalledTorrents            = `No stalled torrents.`
+	MessageStalledReannounce            = `📣`
+	MessageStalledPause                 = `⏸`
+	MessageStalledRemove                = `🗑`
+	MessageTransmissionImport           = `Send the exported .json or .csv file of torrents (or /cancel):`
+	MessageStatsDaily                   = `📅 Daily`
+	MessageStatsMonthly                 = `🗓 Monthly`
+	MessageNoTransferStats              = `No transfer volumes recorded yet.`
+	MessageNoRSS                        = `No rss configured.`
+	MessageNoRSSFeeds                   = `No rss feeds.`
+	MessageRSSPause                     = `⏸`
+	MessageRSSResume                    = `▶`
+	MessageRSSRemove                    = `🗑`
+	MessageRSSConfigFeed                = `⚙ in config`
+
+	// for formatting dates
+	DateFormat  = `2006-01-02`
+	MonthFormat = `2006-01`
 
 	// number of recent logs
 	NumRecentLogs = 20
+
+	// for limiting the length of messages (telegram's limit is 4096 characters)
+	MaxMessageLength = 4000
+
+	// for limiting the number of inline keyboard buttons
+	MaxInlineKeyboardButtons = 100
+	NumInlineKeyboardsPerRow = 5
+	MaxButtonTextLength      = 32
+
+	// for .torrent files
+	MimeTypeTorrent    = `application/x-bittorrent`
+	MaxTorrentFileSize = 10 * 1024 * 1024 // 10MB
+
+	// for exported torrent lists
+	MaxExportFileSize = 20 * 1024 * 1024 // 20MB (telegram's limit for downloading files)
+
+	// for waiting metadata of torrents added with magnet links or urls (for checking disk usage)
+	MetadataWaitMinutes         = 10
+	MetadataPollIntervalSeconds = 5
+
+	// for expiring torrents waiting for user inputs
+	PendingTorrentsExpirationMinutes = 60
+
+	// number of files in a page of file selection
+	NumFilesPerPage = 10
+
+	// for watching the progress of torrents
+	WatchIntervalSeconds = 5
+	WatchTimeoutMinutes  = 30
+	ProgressBarLength    = 10
+
+	// number of torrents to show in the download queue
+	NumQueuedTorrentsToShow = 20
+
+	// number of selected torrents to show in messages
+	NumSelectedTorrentsToShow = 20
+
+	// for expiring torrents selected for actions waiting for user inputs
+	PendingSelectionsExpirationMinutes = 60
+
+	// for expiring list queries kept for navigating pages
+	PendingListQueriesExpirationMinutes = 60
+
+	// number of torrents in a page of torrent list
+	NumTorrentsPerPage = 10
+
+	// for limiting the length of callback data (telegram's limit is 64 bytes)
+	MaxCallbackDataLength = 64
+
+	// for detecting stalled torrents
+	DefaultStalledHours            = 24
+	NumStalledTorrentsToShow       = 20
+	StalledProgressIntervalSeconds = 300 // for recording progresses when stalled torrents are not notified
+
+	// number of days/months in transfer volume reports
+	NumTransferStatsDays   = 31
+	NumTransferStatsMonths = 12
 )
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/meinside/telegram-remotecontrol-bot/cfg"
 	"gorm.io/driver/sqlite"
@@ -19,6 +21,8 @@ const (
 // Database struct
 type Database struct {
 	db *gorm.DB
+
+	transferStatsMutex sync.Mutex // (recording transfer stats reads the last record before writing a new one)
 }
 
 // Log struct
@@ -37,6 +41,82 @@ type Chat struct {
 	UserID string
 }
 
+// TorrentState struct (for tracking changes of torrents)
+type TorrentState struct {
+	gorm.Model
+
+	Instance    string `gorm:"uniqueIndex:idx_torrent_states_instance_hash"` // name of transmission instance
+	HashString  string `gorm:"uniqueIndex:idx_torrent_states_instance_hash"`
+	TorrentID   int
+	Name        string
+	PercentDone float32
+	Error       string
+}
+
+// TorrentStatesMarker struct (for marking torrent states of an instance as initialized, even when there was no torrent)
+type TorrentStatesMarker struct {
+	gorm.Model
+
+	Instance string `gorm:"uniqueIndex"` // name of transmission instance
+}
+
+// TorrentProgress struct (for detecting stalled torrents)
+type TorrentProgress struct {
+	gorm.Model
+
+	Instance     string `gorm:"uniqueIndex:idx_torrent_progresses_instance_hash"` // name of transmission instance
+	HashString   string `gorm:"uniqueIndex:idx_torrent_progresses_instance_hash"`
+	PercentDone  float32
+	ProgressedAt time.Time // when `PercentDone` was changed last time
+	Notified     bool      // whether it was notified as stalled
+}
+
+// Feed struct (for RSS/Atom feeds)
+//
+// (not soft-deleted, so that feeds can be added again with the same urls after being removed)
+type Feed struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	URL         string `gorm:"uniqueIndex"`
+	Include     string // regular expression for titles of items to download
+	Exclude     string // regular expression for titles of items not to download
+	DownloadDir string
+	Instance    string // name of transmission instance
+	Paused      bool
+	PolledAt    time.Time // (zero if never polled)
+	FromConfig  bool      // whether it is one of the feeds in the config
+}
+
+// FeedItem struct (for remembering seen items of feeds)
+type FeedItem struct {
+	gorm.Model
+
+	FeedID uint   `gorm:"uniqueIndex:idx_feed_items_feed_guid"`
+	GUID   string `gorm:"uniqueIndex:idx_feed_items_feed_guid"`
+	Title  string
+}
+
+// TransferStat struct (for daily transfer volumes)
+type TransferStat struct {
+	gorm.Model
+
+	Instance                  string `gorm:"uniqueIndex:idx_transfer_stats_instance_day"` // name of transmission instance
+	Day                       string `gorm:"uniqueIndex:idx_transfer_stats_instance_day"` // in local time (eg. `2006-01-02`)
+	UploadedBytes             int64  // uploaded on the day
+	DownloadedBytes           int64  // downloaded on the day
+	CumulativeUploadedBytes   int64  // cumulative stats of transmission when recorded last time
+	CumulativeDownloadedBytes int64
+}
+
+// TransferVolume struct (for summed transfer volumes of a period)
+type TransferVolume struct {
+	Period          string // day (eg. `2006-01-02`) or month (eg. `2006-01`)
+	UploadedBytes   int64
+	DownloadedBytes int64
+}
+
 // OpenDB opens database and returns it
 func OpenDB() (database *Database, err error) {
 	var configDir string
@@ -50,7 +130,7 @@ func OpenDB() (database *Database, err error) {
 			err = fmt.Errorf("gorm failed to open database: %s", err)
 		} else {
 			// migrate tables
-			if err = db.AutoMigrate(&Log{}, &Chat{}); err == nil {
+			if err = db.AutoMigrate(&Log{}, &Chat{}, &TorrentState{}, &TorrentStatesMarker{}, &TorrentProgress{}, &Feed{}, &FeedItem{}, &TransferStat{}); err == nil {
 				return &Database{db: db}, nil
 			} else {
 				err = fmt.Errorf("gorm failed to migrate database: %s", err)
@@ -113,3 +193,308 @@ func (d *Database) GetChats() (result []Chat) {
 
 	return result
 }
+
+// GetTorrentStates retrieves saved torrent states of given transmission instance
+func (d *Database) GetTorrentStates(instance string) (result []TorrentState) {
+	if tx := d.db.Where("instance = ?", instance).Find(&result); tx.Error != nil {
+		log.Printf("* failed to get torrent states from local database: %s", tx.Error)
+
+		return []TorrentState{}
+	}
+
+	return result
+}
+
+// SaveTorrentState saves the state of a torrent in given transmission instance
+func (d *Database) SaveTorrentState(instance string, torrent RPCResponseTorrent) {
+	if tx := d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "instance"}, {Name: "hash_string"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "torrent_id", "name", "percent_done", "error"}),
+	}).Create(&TorrentState{
+		Instance:    instance,
+		HashString:  torrent.HashString,
+		TorrentID:   torrent.ID,
+		Name:        torrent.Name,
+		PercentDone: torrent.PercentDone,
+		Error:       torrent.Error,
+	}); tx.Error != nil {
+		log.Printf("* failed to save torrent state into local database: %s", tx.Error)
+	}
+}
+
+// ReplaceTorrentStates replaces all saved torrent states of given transmission instance with given torrents
+func (d *Database) ReplaceTorrentStates(instance string, torrents []RPCResponseTorrent) {
+	hashes := []string{}
+	for _, t := range torrents {
+		d.SaveTorrentState(instance, t)
+
+		hashes = append(hashes, t.HashString)
+	}
+
+	// delete states of torrents which are not in the list anymore
+	tx := d.db.Unscoped().Where("instance = ?", instance)
+	if len(hashes) > 0 {
+		tx = tx.Where("hash_string NOT IN ?", hashes)
+	}
+	if tx = tx.Delete(&TorrentState{}); tx.Error != nil {
+		log.Printf("* failed to delete torrent states from local database: %s", tx.Error)
+	}
+
+	// mark as initialized
+	if tx := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&TorrentStatesMarker{Instance: instance}); tx.Error != nil {
+		log.Printf("* failed to save torrent states marker into local database: %s", tx.Error)
+	}
+}
+
+// HasTorrentStates checks if torrent states of given transmission instance were saved before
+// (including the case when there was no torrent)
+func (d *Database) HasTorrentStates(instance string) bool {
+	var count int64
+	if tx := d.db.Model(&TorrentStatesMarker{}).Where("instance = ?", instance).Count(&count); tx.Error != nil {
+		log.Printf("* failed to get torrent states marker from local database: %s", tx.Error)
+	}
+	if count > 0 {
+		return true
+	}
+
+	// (for databases which were saved before markers were introduced)
+	return len(d.GetTorrentStates(instance)) > 0
+}
+
+// UpdateTorrentProgresses updates saved progresses of given torrents in given transmission instance and returns them
+//
+// (progresses of torrents which are not in the list will be deleted)
+func (d *Database) UpdateTorrentProgresses(instance string, torrents []RPCResponseTorrent) (result []TorrentProgress) {
+	var saved []TorrentProgress
+	if tx := d.db.Where("instance = ?", instance).Find(&saved); tx.Error != nil {
+		log.Printf("* failed to get torrent progresses from local database: %s", tx.Error)
+
+		return []TorrentProgress{}
+	}
+	prevs := map[string]TorrentProgress{}
+	for _, p := range saved {
+		prevs[p.HashString] = p
+	}
+
+	now := time.Now()
+	hashes := []string{}
+	for _, t := range torrents {
+		progress, exists := prevs[t.HashString]
+		if !exists || progress.PercentDone != t.PercentDone {
+			progress.Instance = instance
+			progress.HashString = t.HashString
+			progress.PercentDone = t.PercentDone
+			progress.ProgressedAt = now
+			progress.Notified = false
+
+			if tx := d.db.Save(&progress); tx.Error != nil {
+				log.Printf("* failed to save torrent progress into local database: %s", tx.Error)
+			}
+		}
+		result = append(result, progress)
+
+		hashes = append(hashes, t.HashString)
+	}
+
+	// delete progresses of torrents which are not in the list anymore
+	tx := d.db.Unscoped().Where("instance = ?", instance)
+	if len(hashes) > 0 {
+		tx = tx.Where("hash_string NOT IN ?", hashes)
+	}
+	if tx = tx.Delete(&TorrentProgress{}); tx.Error != nil {
+		log.Printf("* failed to delete torrent progresses from local database: %s", tx.Error)
+	}
+
+	return result
+}
+
+// SetTorrentProgressNotified marks the progress of a torrent in given transmission instance as notified
+func (d *Database) SetTorrentProgressNotified(instance, hashString string) {
+	if tx := d.db.Model(&TorrentProgress{}).
+		Where("instance = ? AND hash_string = ?", instance, hashString).
+		Update("notified", true); tx.Error != nil {
+		log.Printf("* failed to update torrent progress in local database: %s", tx.Error)
+	}
+}
+
+// SaveFeed saves a feed (updating the one with the same url)
+//
+// (feeds in the config cannot be updated with it)
+func (d *Database) SaveFeed(feed Feed) (saved Feed, err error) {
+	var existing Feed
+	if tx := d.db.Where("url = ?", feed.URL).Limit(1).Find(&existing); tx.Error != nil {
+		return feed, tx.Error
+	} else if tx.RowsAffected > 0 {
+		if existing.FromConfig {
+			return feed, fmt.Errorf("feed *%d* is in the config, edit the config instead", existing.ID)
+		}
+
+		feed.ID = existing.ID
+		feed.CreatedAt = existing.CreatedAt
+		feed.Paused = existing.Paused
+		feed.PolledAt = existing.PolledAt
+	}
+
+	if tx := d.db.Save(&feed); tx.Error != nil {
+		return feed, tx.Error
+	}
+
+	return feed, nil
+}
+
+// SyncConfigFeeds saves given feeds of the config (updating the ones with the same urls, but keeping their states),
+// and deletes feeds which were in the config before but are not anymore
+func (d *Database) SyncConfigFeeds(feeds []Feed) {
+	urls := []string{}
+	for _, feed := range feeds {
+		feed.FromConfig = true
+		if tx := d.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "url"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "include", "exclude", "download_dir", "instance", "from_config"}),
+		}).Create(&feed); tx.Error != nil {
+			log.Printf("* failed to save feed '%s' into local database: %s", feed.URL, tx.Error)
+		}
+		urls = append(urls, feed.URL)
+	}
+
+	var removed []Feed
+	tx := d.db.Where("from_config = ?", true)
+	if len(urls) > 0 {
+		tx = tx.Where("url NOT IN ?", urls)
+	}
+	if tx = tx.Find(&removed); tx.Error != nil {
+		log.Printf("* failed to get feeds from local database: %s", tx.Error)
+
+		return
+	}
+	for _, feed := range removed {
+		if err := d.DeleteFeed(feed.ID); err != nil {
+			log.Printf("* failed to delete feed '%s' from local database: %s", feed.URL, err)
+		}
+	}
+}
+
+// GetFeeds retrieves all feeds
+func (d *Database) GetFeeds() (result []Feed) {
+	if tx := d.db.Order("id").Find(&result); tx.Error != nil {
+		log.Printf("* failed to get feeds from local database: %s", tx.Error)
+
+		return []Feed{}
+	}
+
+	return result
+}
+
+// GetFeed retrieves a feed with given id
+func (d *Database) GetFeed(id uint) (feed Feed, err error) {
+	err = d.db.First(&feed, id).Error
+
+	return feed, err
+}
+
+// DeleteFeed deletes a feed with given id (along with its seen items)
+func (d *Database) DeleteFeed(id uint) error {
+	if tx := d.db.Delete(&Feed{}, id); tx.Error != nil {
+		return tx.Error
+	}
+
+	return d.db.Unscoped().Where("feed_id = ?", id).Delete(&FeedItem{}).Error
+}
+
+// SetFeedPaused pauses (or resumes) a feed with given id
+func (d *Database) SetFeedPaused(id uint, paused bool) error {
+	return d.db.Model(&Feed{}).Where("id = ?", id).Update("paused", paused).Error
+}
+
+// SetFeedPolled saves the time when a feed with given id was polled
+func (d *Database) SetFeedPolled(id uint, polledAt time.Time) {
+	if tx := d.db.Model(&Feed{}).Where("id = ?", id).Update("polled_at", polledAt); tx.Error != nil {
+		log.Printf("* failed to update feed in local database: %s", tx.Error)
+	}
+}
+
+// HasFeedItem returns whether an item of a feed was already seen
+func (d *Database) HasFeedItem(feedID uint, guid string) bool {
+	var count int64
+	if tx := d.db.Model(&FeedItem{}).Where("feed_id = ? AND guid = ?", feedID, guid).Count(&count); tx.Error != nil {
+		log.Printf("* failed to get feed item from local database: %s", tx.Error)
+
+		// (regard it as seen, for not downloading it again)
+		return true
+	}
+
+	return count > 0
+}
+
+// SaveFeedItem remembers an item of a feed as seen, and returns false if it was already seen
+func (d *Database) SaveFeedItem(feedID uint, guid, title string) bool {
+	tx := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&FeedItem{FeedID: feedID, GUID: guid, Title: title})
+	if tx.Error != nil {
+		log.Printf("* failed to save feed item into local database: %s", tx.Error)
+
+		return false
+	}
+
+	return tx.RowsAffected > 0
+}
+
+// RecordTransferStats adds the transferred bytes since the last record (calculated with given cumulative stats)
+// to the transfer volumes of given day in given transmission instance
+//
+// (nothing is added on the first record, and cumulative stats which were reset will be added as they are)
+func (d *Database) RecordTransferStats(instance, day string, cumulativeUploaded, cumulativeDownloaded int64) {
+	d.transferStatsMutex.Lock()
+	defer d.transferStatsMutex.Unlock()
+
+	var last TransferStat
+	tx := d.db.Where("instance = ?", instance).Order("day desc").Limit(1).Find(&last)
+	if tx.Error != nil {
+		log.Printf("* failed to get transfer stats from local database: %s", tx.Error)
+
+		return
+	}
+
+	delta := func(cumulative, prev int64) int64 {
+		if cumulative < prev {
+			return cumulative
+		}
+		return cumulative - prev
+	}
+
+	stat := TransferStat{Instance: instance, Day: day}
+	if tx.RowsAffected > 0 {
+		if last.Day == day {
+			stat = last
+		}
+		stat.UploadedBytes += delta(cumulativeUploaded, last.CumulativeUploadedBytes)
+		stat.DownloadedBytes += delta(cumulativeDownloaded, last.CumulativeDownloadedBytes)
+	}
+	stat.CumulativeUploadedBytes = cumulativeUploaded
+	stat.CumulativeDownloadedBytes = cumulativeDownloaded
+
+	if tx := d.db.Save(&stat); tx.Error != nil {
+		log.Printf("* failed to save transfer stats into local database: %s", tx.Error)
+	}
+}
+
+// GetTransferVolumes retrieves transfer volumes of given transmission instances since given day,
+// summed by days (or months), in reverse chronological order
+func (d *Database) GetTransferVolumes(instances []string, since string, monthly bool) (result []TransferVolume) {
+	period := "day"
+	if monthly {
+		period = "substr(day, 1, 7)"
+	}
+
+	if tx := d.db.Model(&TransferStat{}).
+		Select(period+" AS period, SUM(uploaded_bytes) AS uploaded_bytes, SUM(downloaded_bytes) AS downloaded_bytes").
+		Where("instance IN ? AND day >= ?", instances, since).
+		Group("period").
+		Order("period desc").
+		Scan(&result); tx.Error != nil {
+		log.Printf("* failed to get transfer volumes from local database: %s", tx.Error)
+
+		return []TransferVolume{}
+	}
+
+	return result
+}
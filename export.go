@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// torrent in exported files
+type exportedTorrent struct {
+	Instance    string `json:"instance"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	HashString  string `json:"hash"`
+	MagnetLink  string `json:"magnet"`
+	DownloadDir string `json:"download_dir"`
+	Status      string `json:"status"`
+}
+
+// columns of exported .csv files
+var exportedCSVColumns = []string{"instance", "id", "name", "hash", "magnet", "download_dir", "status"}
+
+// generate a magnet link with given info hash and name
+func magnetLink(hashString, name string) string {
+	return fmt.Sprintf("magnet:?xt=urn:btih:%s&dn=%s", hashString, url.QueryEscape(name))
+}
+
+// parse transmission export command, and return the exported file
+// (of all instances, or of the instance specified with `@name`)
+//
+// `/trexport [@instance] [csv]`
+func parseTransmissionExportCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (filename string, data []byte, message string) {
+	instances := config.TransmissionInstances
+	instance, txt, found := extractInstance(config, txt)
+	if found {
+		instances = []cfg.TransmissionInstance{instance}
+	}
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionExport, "", 1)))
+	asCSV := slices.Contains(params, consts.ParamExportCSV)
+
+	lines := []string{}
+	exported := []exportedTorrent{}
+	for _, instance := range instances {
+		var torrents []RPCResponseTorrent
+		var err error
+		if client, ok := transmissionClientFor(instance); ok {
+			torrents, err = client.GetTorrentsForExport(ctx)
+		} else {
+			torrents, err = torrentClientFor(instance).GetTorrents(ctx)
+		}
+		if err != nil {
+			lines = append(lines, withInstanceName(config, instance, fmt.Sprintf("Failed to export torrents: %s", err)))
+			continue
+		}
+
+		numNoMagnets := 0
+		for _, t := range torrents {
+			// (torrents without info hashes, eg. aria2 downloads of magnet metadata, cannot be re-added)
+			magnet := t.MagnetLink
+			if len(magnet) <= 0 && len(t.HashString) > 0 {
+				magnet = magnetLink(t.HashString, t.Name)
+			}
+			if len(magnet) <= 0 {
+				numNoMagnets++
+			}
+			exported = append(exported, exportedTorrent{
+				Instance:    instance.Name,
+				ID:          t.ID,
+				Name:        t.Name,
+				HashString:  t.HashString,
+				MagnetLink:  magnet,
+				DownloadDir: t.DownloadDir,
+				Status:      statusToName(t.Status),
+			})
+		}
+		if numNoMagnets > 0 {
+			lines = append(lines, withInstanceName(config, instance, fmt.Sprintf("Exported %d torrent(s), %d of them without magnet links.", len(torrents), numNoMagnets)))
+		} else {
+			lines = append(lines, withInstanceName(config, instance, fmt.Sprintf("Exported %d torrent(s).", len(torrents))))
+		}
+	}
+	message = strings.Join(lines, "\n")
+
+	if len(exported) <= 0 {
+		if len(lines) > 0 {
+			return "", nil, message
+		}
+		return "", nil, consts.MessageTransmissionNoTorrents
+	}
+
+	filename = fmt.Sprintf("torrents-%s", time.Now().Format("20060102-150405"))
+	var err error
+	if asCSV {
+		filename += ".csv"
+		data, err = exportedTorrentsToCSV(exported)
+	} else {
+		filename += ".json"
+		data, err = json.MarshalIndent(exported, "", "  ")
+	}
+	if err != nil {
+		return "", nil, fmt.Sprintf("Failed to export torrents: %s", err)
+	}
+
+	return filename, data, message
+}
+
+// convert given torrents to .csv
+func exportedTorrentsToCSV(torrents []exportedTorrent) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	records := [][]string{exportedCSVColumns}
+	for _, t := range torrents {
+		records = append(records, []string{t.Instance, strconv.Itoa(t.ID), t.Name, t.HashString, t.MagnetLink, t.DownloadDir, t.Status})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parse given exported file (.json or .csv)
+func parseExportedTorrents(filename string, data []byte) (torrents []exportedTorrent, err error) {
+	if strings.ToLower(filepath.Ext(filename)) == ".json" || strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		if err = json.Unmarshal(data, &torrents); err != nil {
+			return nil, fmt.Errorf("failed to parse exported .json file: %s", err)
+		}
+		return torrents, nil
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse exported .csv file: %s", err)
+	}
+	if len(records) <= 0 {
+		return nil, nil
+	}
+
+	// (columns are looked up with the header)
+	columns := map[string]int{}
+	for i, column := range records[0] {
+		columns[strings.TrimSpace(column)] = i
+	}
+	value := func(record []string, column string) string {
+		if i, exists := columns[column]; exists && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	for _, record := range records[1:] {
+		id, _ := strconv.Atoi(value(record, "id"))
+		torrents = append(torrents, exportedTorrent{
+			Instance:    value(record, "instance"),
+			ID:          id,
+			Name:        value(record, "name"),
+			HashString:  value(record, "hash"),
+			MagnetLink:  value(record, "magnet"),
+			DownloadDir: value(record, "download_dir"),
+			Status:      value(record, "status"),
+		})
+	}
+
+	return torrents, nil
+}
+
+// import torrents from given exported file, re-adding missing ones with their magnet links,
+// and return the resulting message
+//
+// (torrents will be added to the instance specified with `@name` in `txt`,
+// or to the ones they were exported from)
+func importTorrents(
+	ctx context.Context,
+	config cfg.Config,
+	db *Database,
+	txt string,
+	filename string,
+	data []byte,
+) string {
+	torrents, err := parseExportedTorrents(filename, data)
+	if err != nil {
+		return err.Error()
+	}
+	if len(torrents) <= 0 {
+		return consts.MessageTransmissionNoTorrents
+	}
+
+	target, _, targetFound := extractInstance(config, txt)
+
+	// hashes of existing torrents in each instance
+	existing := map[string]map[string]bool{}
+	hashes := func(instance cfg.TransmissionInstance) (map[string]bool, error) {
+		if hashes, exists := existing[instance.Name]; exists {
+			return hashes, nil
+		}
+		torrents, err := torrentClientFor(instance).GetTorrents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		existing[instance.Name] = map[string]bool{}
+		for _, t := range torrents {
+			existing[instance.Name][strings.ToLower(t.HashString)] = true
+		}
+		return existing[instance.Name], nil
+	}
+
+	var added, duplicated, failed []string
+	for _, t := range torrents {
+		instance := target
+		if !targetFound {
+			instance = instanceNamed(config, t.Instance)
+		}
+		name := withInstanceName(config, instance, fmt.Sprintf("_%s_", removeMarkdownChars(t.Name, " ")))
+
+		hash := strings.ToLower(t.HashString)
+		magnet := t.MagnetLink
+		if len(magnet) <= 0 && len(hash) > 0 {
+			magnet = magnetLink(hash, t.Name)
+		}
+		metadata, err := parseMagnet(magnet)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (no valid magnet link)", name))
+			continue
+		}
+		if len(hash) <= 0 {
+			hash = metadata.InfoHash
+		}
+
+		hs, err := hashes(instance)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s)", name, err))
+			continue
+		}
+		if hs[hash] {
+			duplicated = append(duplicated, name)
+			continue
+		}
+
+		// (stopped torrents will be added paused)
+		message, _, ok := addTorrent(ctx, config, instance, db, TorrentSource{Filename: magnet}, t.DownloadDir, t.Status == statusToName(TorrentStatusStopped))
+		if !ok {
+			failed = append(failed, fmt.Sprintf("%s (%s)", name, removeMarkdownChars(message, " ")))
+			continue
+		}
+		added = append(added, name)
+		hs[hash] = true
+	}
+
+	lines := []string{fmt.Sprintf("Imported %d torrent(s): %d added, %d duplicated, %d failed", len(torrents), len(added), len(duplicated), len(failed))}
+	for _, result := range []struct {
+		title string
+		names []string
+	}{
+		{"*added*", added},
+		{"*duplicated*", duplicated},
+		{"*failed*", failed},
+	} {
+		if len(result.names) <= 0 {
+			continue
+		}
+
+		lines = append(lines, "", result.title)
+		for i, name := range result.names {
+			if i >= consts.NumSelectedTorrentsToShow {
+				lines = append(lines, fmt.Sprintf("  ... and %d more", len(result.names)-i))
+				break
+			}
+			lines = append(lines, "  "+name)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
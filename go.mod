@@ -3,6 +3,7 @@ module github.com/meinside/telegram-remotecontrol-bot
 go 1.26.0
 
 require (
+	github.com/fsnotify/fsnotify v1.10.1
 	github.com/infisical/go-sdk v0.8.0
 	github.com/jessevdk/go-flags v1.6.1
 	github.com/meinside/rpi-tools v0.3.0
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// get the transmission instance specified with `@name` in given command text,
+// and return the command text without it
+//
+// (when there is only one instance, it will be returned even if not specified)
+func extractInstance(
+	config cfg.Config,
+	txt string,
+) (instance cfg.TransmissionInstance, rest string, found bool) {
+	fields := strings.Fields(txt)
+
+	name := ""
+	filtered := []string{}
+	for _, field := range fields {
+		if n, ok := strings.CutPrefix(field, "@"); ok && len(name) <= 0 {
+			name = n
+		} else {
+			filtered = append(filtered, field)
+		}
+	}
+	rest = strings.Join(filtered, " ")
+
+	if len(name) > 0 {
+		instance, found = config.TransmissionInstance(name)
+	} else if len(config.TransmissionInstances) == 1 {
+		instance, found = config.TransmissionInstances[0], true
+	}
+
+	return instance, rest, found
+}
+
+// get the transmission instance with given name (first one if not specified or not found)
+func instanceNamed(
+	config cfg.Config,
+	name string,
+) cfg.TransmissionInstance {
+	if instance, exists := config.TransmissionInstance(name); exists {
+		return instance
+	}
+	return config.TransmissionInstances[0]
+}
+
+// prepend `@name` of given instance to the parameters of given command
+func instanceCommand(
+	instance cfg.TransmissionInstance,
+	cmd string,
+) string {
+	return fmt.Sprintf("%s @%s", cmd, instance.Name)
+}
+
+// generate inline keyboards for selecting a transmission instance for given command text
+func instanceKeyboards(
+	config cfg.Config,
+	txt string,
+) (keyboards [][]bot.InlineKeyboardButton) {
+	cmd, params, _ := strings.Cut(txt, " ")
+
+	for _, instance := range config.TransmissionInstances {
+		data := instanceCommand(instance, cmd)
+		if len(params) > 0 {
+			data += " " + params
+		}
+
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(instance.Name).
+				SetCallbackData(data),
+		})
+	}
+
+	// add cancel button
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageCancel).
+			SetCallbackData(consts.CommandCancel).
+			SetStyle(bot.KeyboardStyleDanger),
+	})
+
+	return keyboards
+}
+
+// prepend the name of given instance to given message, when there are multiple instances
+func withInstanceName(
+	config cfg.Config,
+	instance cfg.TransmissionInstance,
+	message string,
+) string {
+	if len(config.TransmissionInstances) > 1 {
+		return fmt.Sprintf("[%s] %s", removeMarkdownChars(instance.Name, " "), message)
+	}
+	return message
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// query for filtering, sorting, and paginating the torrent list
+//
+// `/trlist [@instance] [downloading | seeding | stopped | error] [sort:name | size | progress | rate | added] [p:page] [name]`
+//
+// (`/trlist [@instance] q:key p:page` for the query kept with the key, eg. for navigating pages)
+type torrentListQuery struct {
+	Status string // one of `consts.ParamListStatus*`
+	SortBy string // one of `consts.ParamListSort*`
+	Name   string // (case-insensitive)
+	Page   int
+	Key    string // key of the kept query (empty if not kept yet)
+}
+
+// list queries kept for navigating pages
+// (as callback data cannot hold long name filters)
+type pendingListQueryPool struct {
+	Queries map[string]pendingListQuery
+	sync.Mutex
+}
+
+type pendingListQuery struct {
+	Query     torrentListQuery
+	CreatedAt time.Time
+}
+
+var pendingListQueries = pendingListQueryPool{
+	Queries: map[string]pendingListQuery{},
+}
+
+// keep given query and return its key (for callback data)
+func (p *pendingListQueryPool) put(query torrentListQuery) string {
+	p.Lock()
+	defer p.Unlock()
+
+	// remove expired ones
+	for k, v := range p.Queries {
+		if time.Since(v.CreatedAt) > consts.PendingListQueriesExpirationMinutes*time.Minute {
+			delete(p.Queries, k)
+		}
+	}
+
+	key := newPendingKey()
+
+	query.Key = key
+	p.Queries[key] = pendingListQuery{
+		Query:     query,
+		CreatedAt: time.Now(),
+	}
+
+	return key
+}
+
+// get a query with given key
+func (p *pendingListQueryPool) get(key string) (query torrentListQuery, exists bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	var pending pendingListQuery
+	if pending, exists = p.Queries[key]; exists {
+		query = pending.Query
+	}
+
+	return query, exists
+}
+
+// parse given parameters of list command
+func parseTorrentListQuery(params []string) (query torrentListQuery) {
+	query.Page = 1
+
+	words := []string{}
+	for _, param := range params {
+		switch {
+		case slices.Contains([]string{
+			consts.ParamListStatusDownloading,
+			consts.ParamListStatusSeeding,
+			consts.ParamListStatusStopped,
+			consts.ParamListStatusError,
+		}, param):
+			query.Status = param
+		case strings.HasPrefix(param, consts.ParamListSortPrefix):
+			query.SortBy = strings.TrimPrefix(param, consts.ParamListSortPrefix)
+		case strings.HasPrefix(param, consts.ParamListPagePrefix):
+			if page, err := strconv.Atoi(strings.TrimPrefix(param, consts.ParamListPagePrefix)); err == nil {
+				query.Page = page
+			}
+		case strings.HasPrefix(param, consts.ParamListQueryPrefix):
+			query.Key = strings.TrimPrefix(param, consts.ParamListQueryPrefix)
+		default:
+			words = append(words, param)
+		}
+	}
+	query.Name = strings.Join(words, " ")
+
+	return query
+}
+
+// check if given torrent matches the query
+func (q torrentListQuery) matches(t RPCResponseTorrent) bool {
+	switch q.Status {
+	case consts.ParamListStatusDownloading:
+		if len(t.Error) > 0 || (t.Status != TorrentStatusDownloading && t.Status != TorrentStatusQueuedToDownload) {
+			return false
+		}
+	case consts.ParamListStatusSeeding:
+		if len(t.Error) > 0 || (t.Status != TorrentStatusSeeding && t.Status != TorrentStatusQueuedToSeed) {
+			return false
+		}
+	case consts.ParamListStatusStopped:
+		if t.Status != TorrentStatusStopped {
+			return false
+		}
+	case consts.ParamListStatusError:
+		if len(t.Error) <= 0 {
+			return false
+		}
+	}
+
+	if len(q.Name) > 0 && !strings.Contains(strings.ToLower(t.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+
+	return true
+}
+
+// filter and sort given torrents with the query
+func (q torrentListQuery) apply(torrents []RPCResponseTorrent) (filtered []RPCResponseTorrent) {
+	for _, t := range torrents {
+		if q.matches(t) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	if compare := q.compare(); compare != nil {
+		slices.SortStableFunc(filtered, compare)
+	}
+
+	return filtered
+}
+
+// get the function for sorting torrents with the query (nil if not sorted)
+//
+// (name in ascending order, others in descending order)
+func (q torrentListQuery) compare() (compare func(a, b RPCResponseTorrent) int) {
+	switch q.SortBy {
+	case consts.ParamListSortName:
+		compare = func(a, b RPCResponseTorrent) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case consts.ParamListSortSize:
+		compare = func(a, b RPCResponseTorrent) int {
+			return compareDesc(a.TotalSize, b.TotalSize)
+		}
+	case consts.ParamListSortProgress:
+		compare = func(a, b RPCResponseTorrent) int {
+			return compareDesc(a.PercentDone, b.PercentDone)
+		}
+	case consts.ParamListSortRate:
+		compare = func(a, b RPCResponseTorrent) int {
+			return compareDesc(a.RateDownload+a.RateUpload, b.RateDownload+b.RateUpload)
+		}
+	case consts.ParamListSortAdded:
+		compare = func(a, b RPCResponseTorrent) int {
+			return compareDesc(a.AddedDate, b.AddedDate)
+		}
+	}
+
+	return compare
+}
+
+// compare given values in descending order
+func compareDesc[T int64 | float32](a, b T) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// generate callback data of list command for given page
+//
+// (query should be kept with its key)
+func (q torrentListQuery) callbackData(
+	config cfg.Config,
+	instances []cfg.TransmissionInstance,
+	page int,
+) string {
+	cmd := consts.CommandTransmissionList
+	if len(instances) == 1 && len(config.TransmissionInstances) > 1 {
+		cmd = instanceCommand(instances[0], cmd)
+	}
+
+	return fmt.Sprintf("%s %s%s %s%d", cmd, consts.ParamListQueryPrefix, q.Key, consts.ParamListPagePrefix, page)
+}
+
+// torrent in the list, with the instance it belongs to
+type torrentListItem struct {
+	Instance cfg.TransmissionInstance
+	Torrent  RPCResponseTorrent
+}
+
+// get the list of torrents (of all instances, or of the instance specified with `@name`)
+// filtered, sorted, and paginated with given parameters,
+// with inline keyboards for showing their details and navigating pages
+func getTransmissionList(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instances := config.TransmissionInstances
+	instance, txt, found := extractInstance(config, txt)
+	if found {
+		instances = []cfg.TransmissionInstance{instance}
+	}
+
+	query := parseTorrentListQuery(strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionList, "", 1))))
+	if len(query.Key) > 0 {
+		kept, exists := pendingListQueries.get(query.Key)
+		if !exists {
+			return consts.MessageListQueryExpired, nil
+		}
+		kept.Page = query.Page
+		query = kept
+	}
+
+	lines := []string{}
+	items := []torrentListItem{}
+	numTotal := 0
+	for _, instance := range instances {
+		torrents, err := torrentClientFor(instance).GetTorrents(ctx)
+		if err != nil {
+			lines = append(lines, withInstanceName(config, instance, err.Error()))
+			continue
+		}
+		numTotal += len(torrents)
+
+		// (sorted within each instance, for keeping torrents grouped by instances)
+		for _, t := range query.apply(torrents) {
+			items = append(items, torrentListItem{Instance: instance, Torrent: t})
+		}
+	}
+
+	if len(items) <= 0 {
+		if len(lines) > 0 {
+			return strings.Join(lines, "\n"), nil
+		}
+		return consts.MessageTransmissionNoTorrents, nil
+	}
+
+	numMatched := len(items)
+	numPages := (numMatched-1)/consts.NumTorrentsPerPage + 1
+	page := max(1, min(query.Page, numPages))
+	items = items[(page-1)*consts.NumTorrentsPerPage : min(page*consts.NumTorrentsPerPage, len(items))]
+
+	lastInstance := ""
+	for _, item := range items {
+		// show the name of instance when it changes
+		if len(config.TransmissionInstances) > 1 && item.Instance.Name != lastInstance {
+			if len(lastInstance) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, fmt.Sprintf("*%s*", removeMarkdownChars(item.Instance.Name, " ")))
+			lastInstance = item.Instance.Name
+		}
+
+		lines = append(lines, torrentListEntry(item.Torrent))
+	}
+	lines = append(lines, `----`)
+	if numMatched < numTotal {
+		lines = append(lines, fmt.Sprintf("%d of total %d torrent(s)", numMatched, numTotal))
+	} else {
+		lines = append(lines, fmt.Sprintf("total %d torrent(s)", numTotal))
+	}
+	if numPages > 1 {
+		lines = append(lines, fmt.Sprintf("page %d/%d", page, numPages))
+	}
+	message = strings.Join(lines, "\n")
+
+	// inline keyboards for showing details
+	for i := 0; i < len(items); {
+		j := i
+		for j < len(items) && items[j].Instance.Name == items[i].Instance.Name {
+			j++
+		}
+
+		torrents := []RPCResponseTorrent{}
+		for _, item := range items[i:j] {
+			torrents = append(torrents, item.Torrent)
+		}
+		keyboards = append(keyboards, torrentInfoKeyboards(items[i].Instance, torrents)...)
+
+		i = j
+	}
+
+	// inline keyboards for navigating pages
+	if numPages > 1 {
+		if len(query.Key) <= 0 {
+			query.Key = pendingListQueries.put(query)
+		}
+
+		buttons := []bot.InlineKeyboardButton{}
+		if page > 1 {
+			buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessagePrevPage).
+				SetCallbackData(query.callbackData(config, instances, page-1)))
+		}
+		if page < numPages {
+			buttons = append(buttons, bot.NewInlineKeyboardButton(consts.MessageNextPage).
+				SetCallbackData(query.callbackData(config, instances, page+1)))
+		}
+		keyboards = append(keyboards, buttons)
+	}
+
+	return message, keyboards
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// torrents selected for actions which need additional user inputs (eg. new location)
+type pendingSelection struct {
+	Instance   string
+	TorrentIDs []int
+	CreatedAt  time.Time
+}
+
+type pendingSelectionPool struct {
+	Selections map[string]pendingSelection
+	sync.Mutex
+}
+
+var pendingSelections = pendingSelectionPool{
+	Selections: map[string]pendingSelection{},
+}
+
+// keep given selection and return its key (for callback data)
+func (p *pendingSelectionPool) put(selection pendingSelection) string {
+	p.Lock()
+	defer p.Unlock()
+
+	// remove expired ones
+	for k, v := range p.Selections {
+		if time.Since(v.CreatedAt) > consts.PendingSelectionsExpirationMinutes*time.Minute {
+			delete(p.Selections, k)
+		}
+	}
+
+	key := newPendingKey()
+
+	selection.CreatedAt = time.Now()
+	p.Selections[key] = selection
+
+	return key
+}
+
+// get a selection with given key
+func (p *pendingSelectionPool) get(key string) (selection pendingSelection, exists bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	selection, exists = p.Selections[key]
+
+	return selection, exists
+}
+
+// select torrents with given parameters:
+//
+// torrent id, `all`, key of a pending selection (`#key`), or filters of list command
+func selectTorrents(
+	instance cfg.TransmissionInstance,
+	torrents []RPCResponseTorrent,
+	params []string,
+) (selected []RPCResponseTorrent, err error) {
+	param := params[0]
+
+	switch {
+	case param == consts.ParamAllTorrents:
+		return torrents, nil
+	case strings.HasPrefix(param, consts.ParamSelectionPrefix):
+		selection, exists := pendingSelections.get(strings.TrimPrefix(param, consts.ParamSelectionPrefix))
+		if !exists || selection.Instance != instance.Name {
+			return nil, fmt.Errorf("%s", consts.MessageSelectionExpired)
+		}
+		for _, t := range torrents {
+			for _, id := range selection.TorrentIDs {
+				if t.ID == id {
+					selected = append(selected, t)
+				}
+			}
+		}
+		return selected, nil
+	}
+
+	if id, err := strconv.Atoi(param); err == nil {
+		for _, t := range torrents {
+			if t.ID == id {
+				return []RPCResponseTorrent{t}, nil
+			}
+		}
+		return nil, fmt.Errorf("no such torrent: %s", param)
+	}
+
+	query := parseTorrentListQuery(params)
+	if len(query.Status) <= 0 && len(query.Name) <= 0 {
+		return nil, fmt.Errorf("not a valid selection: %s", strings.Join(params, " "))
+	}
+	return query.apply(torrents), nil
+}
+
+// generate lines of selected torrents
+func selectedTorrentLines(torrents []RPCResponseTorrent) (lines []string) {
+	for i, t := range torrents {
+		if i >= consts.NumSelectedTorrentsToShow {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(torrents)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("*%d*. _%s_", t.ID, removeMarkdownChars(t.Name, " ")))
+	}
+	return lines
+}
+
+// get ids of given torrents
+func torrentIDs(torrents []RPCResponseTorrent) (ids []int) {
+	for _, t := range torrents {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// generate inline keyboards for picking a torrent (or all of them) for given command
+func torrentPickerKeyboards(
+	cmd string,
+	torrents []RPCResponseTorrent,
+) (keyboards [][]bot.InlineKeyboardButton) {
+	keys := map[string]string{}
+	for _, t := range torrents {
+		keys[fmt.Sprintf("%d. %s", t.ID, t.Name)] = fmt.Sprintf("%s %d", cmd, t.ID)
+	}
+	keyboards = bot.NewInlineKeyboardButtonsAsRowsWithCallbackData(keys)
+
+	// add 'all' and cancel buttons
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageAllTorrents).
+			SetCallbackData(fmt.Sprintf("%s %s", cmd, consts.ParamAllTorrents)).
+			SetStyle(bot.KeyboardStylePrimary),
+	}, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageCancel).
+			SetCallbackData(consts.CommandCancel).
+			SetStyle(bot.KeyboardStyleDanger),
+	})
+
+	return keyboards
+}
+
+// parse transmission verify/reannounce command, and return the target to watch (for verification)
+//
+// `/trverify [@instance] [id | all | filters]`
+//
+// `/trreannounce [@instance] [id | all | filters]`
+func parseTransmissionVerifyCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton, target *watchTarget) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt), nil
+	}
+	client, ok := transmissionClientFor(instance)
+	if !ok {
+		return consts.MessageNotSupportedByClient, nil, nil
+	}
+
+	cmd := consts.CommandTransmissionVerify
+	if strings.HasPrefix(txt, consts.CommandTransmissionReannounce) {
+		cmd = consts.CommandTransmissionReannounce
+	}
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, cmd, "", 1)))
+
+	torrents, err := client.GetTorrents(ctx)
+	if err != nil {
+		return err.Error(), nil, nil
+	}
+	if len(torrents) <= 0 {
+		return consts.MessageTransmissionNoTorrents, nil, nil
+	}
+
+	// if no torrent is given, show a picker
+	if len(params) <= 0 {
+		message = consts.MessageTransmissionVerify
+		if cmd == consts.CommandTransmissionReannounce {
+			message = consts.MessageTransmissionReannounce
+		}
+		return message, torrentPickerKeyboards(instanceCommand(instance, cmd), torrents), nil
+	}
+
+	selected, err := selectTorrents(instance, torrents, params)
+	if err != nil {
+		return err.Error(), nil, nil
+	}
+	if len(selected) <= 0 {
+		return consts.MessageTransmissionNoTorrents, nil, nil
+	}
+	ids := torrentIDs(selected)
+
+	if cmd == consts.CommandTransmissionReannounce {
+		if err := client.ReannounceTorrents(ctx, ids); err != nil {
+			return fmt.Sprintf("Failed to reannounce torrents: %s", err), nil, nil
+		}
+
+		lines := append([]string{fmt.Sprintf("Reannounced %d torrent(s):", len(selected))}, selectedTorrentLines(selected)...)
+		return withInstanceName(config, instance, strings.Join(lines, "\n")), nil, nil
+	}
+
+	if err := client.VerifyTorrents(ctx, ids); err != nil {
+		return fmt.Sprintf("Failed to verify torrents: %s", err), nil, nil
+	}
+
+	// watch the progress of verification
+	lines := append([]string{fmt.Sprintf("Started verifying %d torrent(s):", len(selected))}, selectedTorrentLines(selected)...)
+	return withInstanceName(config, instance, strings.Join(lines, "\n")), watchKeyboards(), &watchTarget{
+		Instances:    []cfg.TransmissionInstance{instance},
+		TorrentIDs:   ids,
+		Verification: true,
+	}
+}
+
+// parse transmission location command
+//
+// `/trlocation [@instance] [id | all | filters]` for selecting torrents,
+//
+// `/trlocation [@instance] [#key] [location index] [move | set]` for changing their location
+//
+// (with `set`, local data will not be moved but looked for in the new location)
+func parseTransmissionLocationCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+	client, ok := transmissionClientFor(instance)
+	if !ok {
+		return consts.MessageNotSupportedByClient, nil
+	}
+	if len(config.DownloadLocations) <= 0 {
+		return consts.MessageNoDownloadLocations, nil
+	}
+	cmd := instanceCommand(instance, consts.CommandTransmissionLocation)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionLocation, "", 1)))
+
+	torrents, err := client.GetTorrents(ctx)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if len(torrents) <= 0 {
+		return consts.MessageTransmissionNoTorrents, nil
+	}
+
+	// if no torrent is given, show a picker
+	if len(params) <= 0 {
+		return consts.MessageTransmissionLocationTorrent, torrentPickerKeyboards(cmd, torrents)
+	}
+
+	// change location of selected torrents
+	if strings.HasPrefix(params[0], consts.ParamSelectionPrefix) && len(params) >= 3 {
+		selected, err := selectTorrents(instance, torrents, params[:1])
+		if err != nil {
+			return err.Error(), nil
+		}
+		if len(selected) <= 0 {
+			return consts.MessageTransmissionNoTorrents, nil
+		}
+
+		index, err := strconv.Atoi(params[1])
+		if err != nil || index < 0 || index >= len(config.DownloadLocations) {
+			return fmt.Sprintf("not a valid download location: %s", params[1]), nil
+		}
+		location := config.DownloadLocations[index]
+		move := params[2] == consts.ParamLocationMove
+
+		if err := client.SetTorrentsLocation(ctx, torrentIDs(selected), location.Path, move); err != nil {
+			return fmt.Sprintf("Failed to change location: %s", err), nil
+		}
+
+		result := fmt.Sprintf("Changed location of %d torrent(s) to %s", len(selected), removeMarkdownChars(location.Path, " "))
+		if move {
+			result += " (moving data)"
+		}
+		lines := append([]string{result + ":"}, selectedTorrentLines(selected)...)
+
+		// (verifying them will be needed when their data were not moved by transmission)
+		return withInstanceName(config, instance, strings.Join(lines, "\n")), [][]bot.InlineKeyboardButton{
+			{
+				bot.NewInlineKeyboardButton(consts.MessageVerify).
+					SetCallbackData(fmt.Sprintf("%s %s", instanceCommand(instance, consts.CommandTransmissionVerify), params[0])),
+			},
+		}
+	}
+
+	// select torrents, and show download locations
+	selected, err := selectTorrents(instance, torrents, params)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if len(selected) <= 0 {
+		return consts.MessageTransmissionNoTorrents, nil
+	}
+	key := consts.ParamSelectionPrefix + pendingSelections.put(pendingSelection{
+		Instance:   instance.Name,
+		TorrentIDs: torrentIDs(selected),
+	})
+
+	lines := append([]string{fmt.Sprintf("Select new location of %d torrent(s):", len(selected))}, selectedTorrentLines(selected)...)
+	for i, location := range config.DownloadLocations {
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(fmt.Sprintf("%s %s", consts.MessageLocationMove, location.Name)).
+				SetCallbackData(fmt.Sprintf("%s %s %d %s", cmd, key, i, consts.ParamLocationMove)),
+			bot.NewInlineKeyboardButton(fmt.Sprintf("%s %s", consts.MessageLocationSet, location.Name)).
+				SetCallbackData(fmt.Sprintf("%s %s %d %s", cmd, key, i, consts.ParamLocationSet)),
+		})
+	}
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageCancel).
+			SetCallbackData(consts.CommandCancel).
+			SetStyle(bot.KeyboardStyleDanger),
+	})
+
+	return withInstanceName(config, instance, strings.Join(lines, "\n")), keyboards
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TorrentMetadata for metadata parsed from .torrent files or magnet links
+type TorrentMetadata struct {
+	Name      string
+	InfoHash  string // lowercased hex string
+	TotalSize int64  // 0 if unknown
+	NumFiles  int    // 0 if unknown
+	Trackers  []string
+}
+
+// parse given bencoded metainfo (contents of .torrent file)
+//
+// https://www.bittorrent.org/beps/bep_0003.html#metainfo-files
+func parseMetainfo(data []byte) (metadata TorrentMetadata, err error) {
+	d := bencodeDecoder{data: data}
+
+	var decoded any
+	if decoded, err = d.decodeAll(); err != nil {
+		return metadata, fmt.Errorf("not a bencoded data: %s", err)
+	}
+
+	dict, ok := decoded.(map[string]any)
+	if !ok {
+		return metadata, fmt.Errorf("metainfo is not a dictionary")
+	}
+	info, ok := dict["info"].(map[string]any)
+	if !ok {
+		return metadata, fmt.Errorf("metainfo has no 'info' dictionary")
+	}
+
+	// info hash
+	//
+	// (v2-only torrents have sha-256 info hashes, which are truncated to 20 bytes for btih as in BEP-52)
+	_, hasPieces := info["pieces"].(string)
+	if version, _ := info["meta version"].(int64); version == 2 && !hasPieces {
+		hash := sha256.Sum256(d.rawInfo)
+		metadata.InfoHash = hex.EncodeToString(hash[:sha1.Size])
+	} else {
+		hash := sha1.Sum(d.rawInfo)
+		metadata.InfoHash = hex.EncodeToString(hash[:])
+	}
+
+	// name
+	if name, ok := info["name"].(string); ok {
+		metadata.Name = name
+	}
+
+	// files and their sizes
+	if length, ok := info["length"].(int64); ok { // single file
+		metadata.TotalSize = length
+		metadata.NumFiles = 1
+	} else if files, ok := info["files"].([]any); ok { // multiple files
+		for _, f := range files {
+			if file, ok := f.(map[string]any); ok {
+				if length, ok := file["length"].(int64); ok {
+					metadata.TotalSize += length
+				}
+				metadata.NumFiles++
+			}
+		}
+	} else if tree, ok := info["file tree"].(map[string]any); ok { // v2 file tree (BEP-52)
+		metadata.TotalSize, metadata.NumFiles = walkFileTree(tree)
+	} else {
+		return metadata, fmt.Errorf("metainfo has no files")
+	}
+	if !hasPieces {
+		if _, ok := info["file tree"].(map[string]any); !ok {
+			return metadata, fmt.Errorf("metainfo has neither 'pieces' nor 'file tree'")
+		}
+	}
+
+	// trackers
+	if announce, ok := dict["announce"].(string); ok {
+		metadata.Trackers = append(metadata.Trackers, announce)
+	}
+	if tiers, ok := dict["announce-list"].([]any); ok {
+		for _, tier := range tiers {
+			if trackers, ok := tier.([]any); ok {
+				for _, t := range trackers {
+					if tracker, ok := t.(string); ok && !slices.Contains(metadata.Trackers, tracker) {
+						metadata.Trackers = append(metadata.Trackers, tracker)
+					}
+				}
+			}
+		}
+	}
+
+	return metadata, nil
+}
+
+// walk v2 file tree and return the total size and number of files
+func walkFileTree(tree map[string]any) (totalSize int64, numFiles int) {
+	for name, node := range tree {
+		if n, ok := node.(map[string]any); ok {
+			if name == "" { // file
+				if length, ok := n["length"].(int64); ok {
+					totalSize += length
+				}
+				numFiles++
+			} else { // directory
+				size, num := walkFileTree(n)
+				totalSize += size
+				numFiles += num
+			}
+		}
+	}
+	return totalSize, numFiles
+}
+
+// parse given magnet link
+//
+// https://www.bittorrent.org/beps/bep_0009.html#magnet-uri-format
+func parseMagnet(magnet string) (metadata TorrentMetadata, err error) {
+	var u *url.URL
+	if u, err = url.Parse(magnet); err != nil || u.Scheme != "magnet" {
+		return metadata, fmt.Errorf("not a valid magnet link")
+	}
+	query := u.Query()
+
+	for _, xt := range query["xt"] {
+		if hash, ok := strings.CutPrefix(xt, "urn:btih:"); ok {
+			switch len(hash) {
+			case 40: // hex
+				if _, err := hex.DecodeString(hash); err == nil {
+					metadata.InfoHash = strings.ToLower(hash)
+				}
+			case 32: // base32
+				if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+					metadata.InfoHash = hex.EncodeToString(decoded)
+				}
+			}
+		}
+	}
+	if len(metadata.InfoHash) <= 0 {
+		return metadata, fmt.Errorf("magnet link has no valid info hash")
+	}
+
+	metadata.Name = query.Get("dn")
+	if xl, err := strconv.ParseInt(query.Get("xl"), 10, 64); err == nil {
+		metadata.TotalSize = xl
+	}
+	metadata.Trackers = query["tr"]
+
+	return metadata, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+)
+
+// run a loop which periodically checks torrents and notifies their changes to chats
+func runTorrentNotifier(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+) {
+	_stdout.Printf("starting torrent notifier with interval: %d second(s)", config.TorrentNotificationInterval)
+
+	ticker := time.NewTicker(time.Duration(config.TorrentNotificationInterval) * time.Second)
+	defer ticker.Stop()
+
+	// when states were never saved, just save the current states without notifying
+	initialized := map[string]bool{}
+	for _, instance := range config.TransmissionInstances {
+		initialized[instance.Name] = db.HasTorrentStates(instance.Name)
+	}
+
+	lastErrs := map[string]error{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, instance := range config.TransmissionInstances {
+				torrents, err := torrentClientFor(instance).GetTorrents(ctx)
+				if err != nil {
+					// log only when the error is a new one
+					if lastErr := lastErrs[instance.Name]; lastErr == nil || lastErr.Error() != err.Error() {
+						logError(db, "failed to get torrents of '%s' for notification: %s", instance.Name, err)
+					}
+					lastErrs[instance.Name] = err
+
+					continue
+				}
+				delete(lastErrs, instance.Name)
+
+				if initialized[instance.Name] {
+					if messages := torrentChanges(db.GetTorrentStates(instance.Name), torrents); len(messages) > 0 {
+						broadcast(ctx, client, config, db, withInstanceName(config, instance, strings.Join(messages, "\n")))
+					}
+				}
+
+				db.ReplaceTorrentStates(instance.Name, torrents)
+				initialized[instance.Name] = true
+			}
+		}
+	}
+}
+
+// compare saved states with current torrents and generate messages for notification
+func torrentChanges(
+	states []TorrentState,
+	torrents []RPCResponseTorrent,
+) (messages []string) {
+	prevs := map[string]TorrentState{}
+	for _, s := range states {
+		prevs[s.HashString] = s
+	}
+
+	for _, t := range torrents {
+		name := removeMarkdownChars(t.Name, " ")
+
+		prev, exists := prevs[t.HashString]
+		if !exists {
+			messages = append(messages, fmt.Sprintf("➕ torrent added: *%d*. _%s_", t.ID, name))
+			continue
+		}
+
+		if prev.PercentDone < 1.0 && t.PercentDone >= 1.0 {
+			messages = append(messages, fmt.Sprintf("✅ download finished: *%d*. _%s_", t.ID, name))
+		}
+		if len(prev.Error) <= 0 && len(t.Error) > 0 {
+			messages = append(messages, fmt.Sprintf("⚠️ torrent errored: *%d*. _%s_ (%s)", t.ID, name, removeMarkdownChars(t.Error, " ")))
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// torrent which waits for additional user inputs (eg. confirmation, download location)
+type pendingTorrent struct {
+	UserID    string
+	Torrent   TorrentSource
+	CreatedAt time.Time
+}
+
+type pendingTorrentPool struct {
+	Torrents map[string]pendingTorrent
+	sync.Mutex
+}
+
+var pendingTorrents = pendingTorrentPool{
+	Torrents: map[string]pendingTorrent{},
+}
+
+// keep given torrent and return its key (for callback data)
+func (p *pendingTorrentPool) put(torrent pendingTorrent) string {
+	p.Lock()
+	defer p.Unlock()
+
+	// remove expired ones
+	for k, v := range p.Torrents {
+		if time.Since(v.CreatedAt) > consts.PendingTorrentsExpirationMinutes*time.Minute {
+			delete(p.Torrents, k)
+		}
+	}
+
+	key := newPendingKey()
+
+	torrent.CreatedAt = time.Now()
+	p.Torrents[key] = torrent
+
+	return key
+}
+
+// generate a random key for things waiting for user inputs
+func newPendingKey() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// take out a torrent with given key
+func (p *pendingTorrentPool) take(key string) (torrent pendingTorrent, exists bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	if torrent, exists = p.Torrents[key]; exists {
+		delete(p.Torrents, key)
+	}
+
+	return torrent, exists
+}
+
+// get a torrent with given key (without taking it out)
+func (p *pendingTorrentPool) get(key string) (torrent pendingTorrent, exists bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	torrent, exists = p.Torrents[key]
+
+	return torrent, exists
+}
+
+// add a torrent and remember it, so that it won't be notified as added by someone else,
+// and return the resulting message along with the added torrent (if known) and whether it was added
+func addTorrent(
+	ctx context.Context,
+	config cfg.Config,
+	instance cfg.TransmissionInstance,
+	db *Database,
+	torrent TorrentSource,
+	downloadDir string,
+	paused bool,
+) (string, *RPCResponseTorrent, bool) {
+	message, added, ok := torrentClientFor(instance).AddTorrent(ctx, torrent, downloadDir, paused)
+	if added != nil {
+		db.SaveTorrentState(instance.Name, *added)
+	}
+
+	return withInstanceName(config, instance, message), added, ok
+}
+
+// add a torrent whose size is unknown yet (eg. url of .torrent file) with checking disk usage,
+// and return the resulting message along with whether it was added
+//
+// (the torrent will be started right away, and disk usage will be checked in background
+// after its metadata is fetched, stopping it with a broadcast warning when needed)
+func addTorrentCheckingDiskUsage(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	instance cfg.TransmissionInstance,
+	db *Database,
+	torrent TorrentSource,
+	downloadDir string,
+	paused bool,
+) (message string, ok bool) {
+	var added *RPCResponseTorrent
+	message, added, ok = addTorrent(ctx, config, instance, db, torrent, downloadDir, paused)
+
+	// (torrents which were not found after being added cannot be checked)
+	if ok && added != nil && !paused && config.MaxDiskUsagePercent > 0 {
+		go checkDiskUsageOfAddedTorrent(ctx, client, config, instance, db, strconv.Itoa(added.ID))
+	}
+
+	return message, ok
+}
+
+// wait until the metadata of given (started) torrent is fetched and its size is known,
+// then check disk usage and stop it with a broadcast warning if needed
+func checkDiskUsageOfAddedTorrent(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	instance cfg.TransmissionInstance,
+	db *Database,
+	torrentID string,
+) {
+	t, err := waitForTorrentSize(ctx, instance, torrentID)
+
+	var warning string
+	if err != nil || t.TotalSize <= 0 {
+		if ctx.Err() != nil {
+			return
+		}
+		warning = consts.MessageTransmissionSizeUnknown
+	} else if warning = checkDiskUsage(ctx, config, instance, t.DownloadDir, t.TotalSize); len(warning) > 0 {
+		_ = torrentClientFor(instance).PauseTorrent(ctx, torrentID)
+		warning = fmt.Sprintf("%s\n\n%s", warning, consts.MessageTransmissionStopped)
+	} else {
+		return
+	}
+
+	name := t.Name
+	if len(name) <= 0 {
+		name = torrentID
+	}
+
+	broadcastWithKeyboards(ctx, client, config, db, withInstanceName(config, instance, fmt.Sprintf("_%s_\n\n%s", removeMarkdownChars(name, " "), warning)), [][]bot.InlineKeyboardButton{
+		{
+			bot.NewInlineKeyboardButton(consts.MessageStartAnyway).
+				SetCallbackData(fmt.Sprintf("%s %s", instanceCommand(instance, consts.CommandTransmissionResume), torrentID)),
+			bot.NewInlineKeyboardButton(consts.MessageRemove).
+				SetCallbackData(fmt.Sprintf("%s %s", instanceCommand(instance, consts.CommandTransmissionRemove), torrentID)).
+				SetStyle(bot.KeyboardStyleDanger),
+		},
+	})
+}
+
+// get given torrent, waiting (for a while) until its metadata is fetched and its size is known
+func waitForTorrentSize(
+	ctx context.Context,
+	instance cfg.TransmissionInstance,
+	torrentID string,
+) (torrent RPCResponseTorrent, err error) {
+	ctxWait, cancelWait := context.WithTimeout(ctx, consts.MetadataWaitMinutes*time.Minute)
+	defer cancelWait()
+
+	for {
+		if torrent, err = torrentClientFor(instance).GetTorrent(ctxWait, torrentID); err == nil && torrent.TotalSize > 0 {
+			return torrent, nil
+		}
+
+		select {
+		case <-ctxWait.Done():
+			return torrent, err
+		case <-time.After(consts.MetadataPollIntervalSeconds * time.Second):
+		}
+	}
+}
+
+// get the total size of given torrent (0 if unknown)
+func torrentSize(torrent TorrentSource) int64 {
+	var metadata TorrentMetadata
+	if len(torrent.Metainfo) > 0 {
+		metadata, _ = parseMetainfo(torrent.Metainfo)
+	} else if strings.HasPrefix(torrent.Filename, "magnet:") {
+		metadata, _ = parseMagnet(torrent.Filename)
+	}
+	return metadata.TotalSize
+}
+
+// check if downloading given size of data into given directory will push its disk usage past the threshold,
+// and return a warning message if so
+//
+// (empty `downloadDir` for transmission's default download directory)
+func checkDiskUsage(
+	ctx context.Context,
+	config cfg.Config,
+	instance cfg.TransmissionInstance,
+	downloadDir string,
+	size int64,
+) (warning string) {
+	if config.MaxDiskUsagePercent <= 0 || size <= 0 {
+		return ""
+	}
+
+	client, ok := transmissionClientFor(instance)
+
+	if len(downloadDir) <= 0 {
+		if !ok {
+			return ""
+		}
+
+		if session, err := client.GetSession(ctx); err == nil {
+			downloadDir = session.DownloadDir
+		} else {
+			return ""
+		}
+	}
+
+	// get free space from transmission,
+	var all, free int64
+	if ok {
+		if fs, err := client.GetFreeSpace(ctx, downloadDir); err == nil {
+			all, free = fs.TotalSize, fs.SizeBytes
+		}
+	}
+	// or from local file system (only when transmission is running locally)
+	if (all <= 0 || free <= 0) && isLocalHost(instance.Host) {
+		if a, f, err := diskSpace(downloadDir); err == nil {
+			all, free = int64(a), int64(f)
+		}
+	}
+
+	dir := removeMarkdownChars(downloadDir, " ")
+	if free > 0 && size > free {
+		return fmt.Sprintf("⚠️ not enough space in %s: *%s* required, *%s* available", dir, readableSize(size), readableSize(free))
+	}
+	if all > 0 {
+		if usage := float64(all-free+size) / float64(all) * 100.0; usage > float64(config.MaxDiskUsagePercent) {
+			return fmt.Sprintf("⚠️ disk usage of %s will be *%.1f%%* (> %d%%) after downloading *%s*", dir, usage, config.MaxDiskUsagePercent, readableSize(size))
+		}
+	}
+
+	return ""
+}
+
+// get the default download directory of given user
+//
+// (empty string for transmission's default download directory)
+func defaultDownloadDir(
+	config cfg.Config,
+	userID string,
+) string {
+	if name, exists := config.DefaultDownloadLocations[userID]; exists {
+		for _, location := range config.DownloadLocations {
+			if location.Name == name {
+				return location.Path
+			}
+		}
+	}
+	return ""
+}
+
+// keep given torrent and show its preview with inline keyboards for adding it
+func previewTorrent(
+	ctx context.Context,
+	config cfg.Config,
+	userID string,
+	torrent TorrentSource,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	key := pendingTorrents.put(pendingTorrent{
+		UserID:  userID,
+		Torrent: torrent,
+	})
+
+	return torrentPreview(ctx, config, torrent), previewKeyboards(config, key)
+}
+
+// generate a preview message of given torrent
+func torrentPreview(
+	ctx context.Context,
+	config cfg.Config,
+	torrent TorrentSource,
+) string {
+	var metadata TorrentMetadata
+	var err error
+	if len(torrent.Metainfo) > 0 {
+		metadata, err = parseMetainfo(torrent.Metainfo)
+	} else if strings.HasPrefix(torrent.Filename, "magnet:") {
+		metadata, err = parseMagnet(torrent.Filename)
+	} else {
+		return fmt.Sprintf("torrent: %s", torrent.Filename)
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to parse given torrent: %s", err)
+	}
+
+	name := metadata.Name
+	if len(name) <= 0 {
+		name = consts.MessageUnknownName
+	}
+	size := "unknown"
+	if metadata.TotalSize > 0 {
+		size = readableSize(metadata.TotalSize)
+	}
+	files := "unknown"
+	if metadata.NumFiles > 0 {
+		files = strconv.Itoa(metadata.NumFiles)
+	}
+
+	lines := []string{
+		fmt.Sprintf("_%s_", removeMarkdownChars(name, " ")),
+		fmt.Sprintf("  ┖ hash: `%s`", metadata.InfoHash),
+		fmt.Sprintf("  ┖ size: %s", size),
+		fmt.Sprintf("  ┖ files: %s", files),
+		fmt.Sprintf("  ┖ trackers: %d", len(metadata.Trackers)),
+	}
+	for _, tracker := range metadata.Trackers {
+		if u, err := url.Parse(tracker); err == nil && len(u.Host) > 0 {
+			lines = append(lines, fmt.Sprintf("    ┖ %s", removeMarkdownChars(u.Host, " ")))
+		}
+	}
+
+	// warn if it already exists
+	for _, instance := range config.TransmissionInstances {
+		if torrents, err := torrentClientFor(instance).GetTorrents(ctx); err == nil {
+			for _, t := range torrents {
+				if strings.EqualFold(t.HashString, metadata.InfoHash) {
+					lines = append(lines, "", withInstanceName(config, instance, fmt.Sprintf("⚠️ already exists: *%d*. _%s_", t.ID, removeMarkdownChars(t.Name, " "))))
+					break
+				}
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// generate inline keyboards for adding a pending torrent
+func previewKeyboards(
+	config cfg.Config,
+	key string,
+) (keyboards [][]bot.InlineKeyboardButton) {
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageAdd).
+			SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamAdd)).
+			SetStyle(bot.KeyboardStyleSuccess),
+		bot.NewInlineKeyboardButton(consts.MessageAddPaused).
+			SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamAddPaused)),
+	})
+
+	// add 'choose folder' button
+	if len(config.DownloadLocations) > 0 {
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(consts.MessageChooseFolder).
+				SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamChooseFolder)),
+		})
+	}
+
+	// add cancel button
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageCancel).
+			SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamCancel)).
+			SetStyle(bot.KeyboardStyleDanger),
+	})
+
+	return keyboards
+}
+
+// generate inline keyboards for selecting download location of a pending torrent
+func downloadLocationKeyboards(
+	config cfg.Config,
+	key string,
+) (keyboards [][]bot.InlineKeyboardButton) {
+	for i, location := range config.DownloadLocations {
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(fmt.Sprintf("%s (%s)", location.Name, location.Path)).
+				SetCallbackData(fmt.Sprintf("%s %s %d", consts.CommandTransmissionAdd, key, i)),
+		})
+	}
+
+	// add default location button
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageDefaultLocation).
+			SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamDefaultLocation)),
+	})
+
+	// add cancel button
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageCancel).
+			SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamCancel)).
+			SetStyle(bot.KeyboardStyleDanger),
+	})
+
+	return keyboards
+}
+
+// parse transmission add command from callback query
+//
+// `/tradd [@instance] [key] [add | paused | folder | location index | default | cancel] [force]`
+//
+// (with `force`, disk usage will not be checked)
+func parseTransmissionAddCommand(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instance, txt, found := extractInstance(config, txt)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionAdd, "", 1)))
+	if len(params) < 2 {
+		return fmt.Sprintf("not a valid add command: %s", txt), nil
+	}
+	key, action := params[0], params[1]
+
+	// show download locations
+	if action == consts.ParamChooseFolder {
+		if _, exists := pendingTorrents.get(key); !exists {
+			return consts.MessageTransmissionExpired, nil
+		}
+		return consts.MessageTransmissionLocation, downloadLocationKeyboards(config, key)
+	}
+
+	pending, exists := pendingTorrents.get(key)
+	if !exists {
+		return consts.MessageTransmissionExpired, nil
+	}
+
+	// select an instance to add the torrent to
+	if !found && action != consts.ParamCancel {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+
+	var downloadDir string
+	var paused bool
+	switch action {
+	case consts.ParamCancel:
+		pendingTorrents.take(key)
+		return consts.MessageCanceled, nil
+	case consts.ParamAdd:
+		downloadDir = defaultDownloadDir(config, pending.UserID)
+	case consts.ParamAddPaused:
+		downloadDir, paused = defaultDownloadDir(config, pending.UserID), true
+	case consts.ParamDefaultLocation:
+		downloadDir = ""
+	default:
+		if i, err := strconv.Atoi(action); err == nil && i >= 0 && i < len(config.DownloadLocations) {
+			downloadDir = config.DownloadLocations[i].Path
+		} else {
+			return fmt.Sprintf("not a valid download location: %s", action), nil
+		}
+	}
+	force := len(params) > 2 && params[2] == consts.ParamForce
+
+	if !force && config.MaxDiskUsagePercent > 0 {
+		size := torrentSize(pending.Torrent)
+
+		// when the size is known, check disk usage before adding it
+		if size > 0 {
+			if warning := checkDiskUsage(ctx, config, instance, downloadDir, size); len(warning) > 0 {
+				return warning, [][]bot.InlineKeyboardButton{
+					{
+						bot.NewInlineKeyboardButton(consts.MessageAddAnyway).
+							SetCallbackData(fmt.Sprintf("%s %s %s %s", instanceCommand(instance, consts.CommandTransmissionAdd), key, action, consts.ParamForce)),
+						bot.NewInlineKeyboardButton(consts.MessageCancel).
+							SetCallbackData(fmt.Sprintf("%s %s %s", consts.CommandTransmissionAdd, key, consts.ParamCancel)).
+							SetStyle(bot.KeyboardStyleDanger),
+					},
+				}
+			}
+		} else { // or check it after its metadata is fetched
+			pendingTorrents.take(key)
+
+			message, _ = addTorrentCheckingDiskUsage(ctx, client, config, instance, db, pending.Torrent, downloadDir, paused)
+
+			return message, nil
+		}
+	}
+
+	pendingTorrents.take(key)
+
+	message, _, _ = addTorrent(ctx, config, instance, db, pending.Torrent, downloadDir, paused)
+
+	return message, nil
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// constants for qBittorrent
+const (
+	qbittorrentETAInfinity = 8640000 // eta value for infinity
+)
+
+// qBittorrent torrent
+//
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)#get-torrent-list
+type qbittorrentTorrent struct {
+	Hash         string  `json:"hash"`
+	Name         string  `json:"name"`
+	State        string  `json:"state"`
+	Size         int64   `json:"size"` // total size of selected files
+	Progress     float32 `json:"progress"`
+	DLSpeed      int64   `json:"dlspeed"` // B/s
+	UPSpeed      int64   `json:"upspeed"` // B/s
+	ETA          int64   `json:"eta"`     // seconds
+	Ratio        float64 `json:"ratio"`
+	AddedOn      int64   `json:"added_on"`      // unix timestamp
+	CompletionOn int64   `json:"completion_on"` // unix timestamp
+	SeedingTime  int64   `json:"seeding_time"`  // seconds
+	SavePath     string  `json:"save_path"`
+}
+
+// qBittorrent file of a torrent
+type qbittorrentFile struct {
+	Name     string  `json:"name"`
+	Size     int64   `json:"size"`
+	Progress float32 `json:"progress"`
+	Priority int     `json:"priority"` // 0 for not downloading
+}
+
+// qBittorrent tracker of a torrent
+type qbittorrentTracker struct {
+	URL        string `json:"url"`
+	Status     int    `json:"status"` // 2 for working
+	Msg        string `json:"msg"`
+	NumSeeds   int    `json:"num_seeds"`
+	NumLeeches int    `json:"num_leeches"`
+}
+
+// qBittorrent peers of a torrent
+type qbittorrentPeers struct {
+	Peers map[string]struct {
+		Client   string  `json:"client"`
+		Progress float32 `json:"progress"`
+		DLSpeed  int64   `json:"dl_speed"` // B/s
+		UPSpeed  int64   `json:"up_speed"` // B/s
+	} `json:"peers"`
+}
+
+// torrent client for qBittorrent Web API
+//
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)
+type qbittorrentClient struct {
+	rpc    cfg.TransmissionRPCEndpoint
+	ids    *torrentIDMap
+	client *http.Client
+	err    error // error while creating http client
+
+	loginMutex sync.Mutex
+}
+
+// create a new qBittorrent client
+func newQBittorrentClient(rpc cfg.TransmissionRPCEndpoint) *qbittorrentClient {
+	c := &qbittorrentClient{
+		rpc: rpc,
+		ids: newTorrentIDMap(),
+	}
+
+	if c.client, c.err = newHTTPClient(rpc); c.err == nil {
+		c.client.Jar, _ = cookiejar.New(nil) // for keeping the session cookie
+	}
+
+	return c
+}
+
+// log in to qBittorrent Web API
+func (c *qbittorrentClient) login(ctx context.Context) error {
+	c.loginMutex.Lock()
+	defer c.loginMutex.Unlock()
+
+	res, status, err := c.send(ctx, "/auth/login", url.Values{
+		"username": {c.rpc.Username},
+		"password": {c.rpc.Passwd},
+	}.Encode(), "application/x-www-form-urlencoded", false)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(res)) != "Ok." {
+		return fmt.Errorf("failed to log in to qBittorrent (HTTP %d)", status)
+	}
+	return nil
+}
+
+// send a request to qBittorrent Web API
+//
+// (when `relogin` is true, it will log in again and retry when unauthorized)
+func (c *qbittorrentClient) send(
+	ctx context.Context,
+	endpoint string,
+	body, contentType string,
+	relogin bool,
+) (res []byte, status int, err error) {
+	if c.err != nil {
+		return nil, 0, c.err
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, "POST", c.rpc.URL()+endpoint, strings.NewReader(body)); err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Referer", (&url.URL{Scheme: c.rpc.Scheme, Host: req.URL.Host}).String())
+
+	var resp *http.Response
+	if resp, err = c.client.Do(req); err != nil {
+		log.Printf("error while sending request to qBittorrent: %s\n", err.Error())
+
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusForbidden && relogin {
+		if err = c.login(ctx); err != nil {
+			return nil, resp.StatusCode, err
+		}
+		return c.send(ctx, endpoint, body, contentType, false) // XXX - retry
+	}
+
+	res, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("HTTP %d (%s)", resp.StatusCode, strings.TrimSpace(string(res)))
+	}
+
+	return res, resp.StatusCode, err
+}
+
+// send a form request to qBittorrent Web API
+func (c *qbittorrentClient) sendForm(
+	ctx context.Context,
+	endpoint string,
+	params url.Values,
+) (res []byte, err error) {
+	res, _, err = c.send(ctx, endpoint, params.Encode(), "application/x-www-form-urlencoded", true)
+	return res, err
+}
+
+// send a form request to qBittorrent Web API and decode its json response
+func (c *qbittorrentClient) getJSON(
+	ctx context.Context,
+	endpoint string,
+	params url.Values,
+	v any,
+) (err error) {
+	var res []byte
+	if res, err = c.sendForm(ctx, endpoint, params); err == nil {
+		if err = json.Unmarshal(res, v); err != nil {
+			err = fmt.Errorf("malformed response from qBittorrent: %s", string(res))
+		}
+	}
+	return err
+}
+
+// convert qBittorrent torrent to transmission's torrent
+func (c *qbittorrentClient) convert(t qbittorrentTorrent) (torrent RPCResponseTorrent) {
+	torrent = RPCResponseTorrent{
+		ID:             c.ids.id(t.Hash),
+		Status:         qbittorrentStateToStatus(t.State),
+		Name:           t.Name,
+		RateDownload:   t.DLSpeed,
+		RateUpload:     t.UPSpeed,
+		PercentDone:    t.Progress,
+		TotalSize:      t.Size,
+		HashString:     t.Hash,
+		ETA:            t.ETA,
+		UploadRatio:    t.Ratio,
+		AddedDate:      t.AddedOn,
+		DoneDate:       max(0, t.CompletionOn),
+		SecondsSeeding: t.SeedingTime,
+		DownloadDir:    t.SavePath,
+	}
+	if t.ETA >= qbittorrentETAInfinity {
+		torrent.ETA = -2
+	}
+	switch t.State {
+	case "error":
+		torrent.Error = "error"
+	case "missingFiles":
+		torrent.Error = "missing files"
+	}
+	return torrent
+}
+
+// convert qBittorrent torrent state to transmission's torrent status
+func qbittorrentStateToStatus(state string) TorrentStatus {
+	switch state {
+	case "uploading", "stalledUP", "forcedUP":
+		return TorrentStatusSeeding
+	case "queuedUP":
+		return TorrentStatusQueuedToSeed
+	case "downloading", "stalledDL", "metaDL", "forcedDL", "forcedMetaDL", "allocating", "moving":
+		return TorrentStatusDownloading
+	case "queuedDL":
+		return TorrentStatusQueuedToDownload
+	case "checkingUP", "checkingDL", "checkingResumeData":
+		return TorrentStatusVerifyingLocalData
+	case "pausedUP", "pausedDL", "stoppedUP", "stoppedDL", "error", "missingFiles":
+		return TorrentStatusStopped
+	default:
+		return TorrentStatus(-1) // unknown
+	}
+}
+
+// GetTorrents retrieves all torrents.
+func (c *qbittorrentClient) GetTorrents(ctx context.Context) (torrents []RPCResponseTorrent, err error) {
+	var ts []qbittorrentTorrent
+	if err = c.getJSON(ctx, "/torrents/info", url.Values{"sort": {"added_on"}}, &ts); err != nil {
+		return nil, err
+	}
+
+	torrents = []RPCResponseTorrent{}
+	for _, t := range ts {
+		torrents = append(torrents, c.convert(t))
+	}
+	return torrents, nil
+}
+
+// GetTorrent retrieves a torrent with its details.
+func (c *qbittorrentClient) GetTorrent(ctx context.Context, torrentID string) (torrent RPCResponseTorrent, err error) {
+	var hash string
+	if hash, err = c.ids.key(torrentID); err != nil {
+		return torrent, err
+	}
+
+	var ts []qbittorrentTorrent
+	if err = c.getJSON(ctx, "/torrents/info", url.Values{"hashes": {hash}}, &ts); err != nil {
+		return torrent, err
+	}
+	if len(ts) <= 0 {
+		return torrent, fmt.Errorf("no such torrent: %s", torrentID)
+	}
+	torrent = c.convert(ts[0])
+
+	// files
+	var files []qbittorrentFile
+	if err = c.getJSON(ctx, "/torrents/files", url.Values{"hash": {hash}}, &files); err != nil {
+		return torrent, fmt.Errorf("failed to get files of torrent: %s", err)
+	}
+	for _, f := range files {
+		torrent.Files = append(torrent.Files, RPCResponseTorrentFile{
+			Name:           f.Name,
+			Length:         f.Size,
+			BytesCompleted: int64(float64(f.Size) * float64(f.Progress)),
+		})
+	}
+
+	// peers
+	var peers qbittorrentPeers
+	if err = c.getJSON(ctx, "/sync/torrentPeers", url.Values{"hash": {hash}, "rid": {"0"}}, &peers); err != nil {
+		return torrent, fmt.Errorf("failed to get peers of torrent: %s", err)
+	}
+	for address, p := range peers.Peers {
+		torrent.Peers = append(torrent.Peers, RPCResponseTorrentPeer{
+			Address:      address,
+			ClientName:   p.Client,
+			Progress:     p.Progress,
+			RateToClient: p.DLSpeed,
+			RateToPeer:   p.UPSpeed,
+		})
+	}
+
+	// trackers
+	var trackers []qbittorrentTracker
+	if err = c.getJSON(ctx, "/torrents/trackers", url.Values{"hash": {hash}}, &trackers); err != nil {
+		return torrent, fmt.Errorf("failed to get trackers of torrent: %s", err)
+	}
+	for i, t := range trackers {
+		if strings.HasPrefix(t.URL, "** [") { // skip DHT, PeX, and LSD
+			continue
+		}
+		host := t.URL
+		if u, err := url.Parse(t.URL); err == nil && len(u.Host) > 0 {
+			host = u.Host
+		}
+		torrent.TrackerStats = append(torrent.TrackerStats, RPCResponseTorrentTracker{
+			ID:                    i,
+			Host:                  host,
+			Announce:              t.URL,
+			LastAnnounceResult:    t.Msg,
+			LastAnnounceSucceeded: t.Status == 2,
+			SeederCount:           t.NumSeeds,
+			LeecherCount:          t.NumLeeches,
+		})
+	}
+
+	return torrent, nil
+}
+
+// AddTorrent adds a torrent.
+//
+// (added torrent will be returned only when its info hash is known)
+func (c *qbittorrentClient) AddTorrent(ctx context.Context, torrent TorrentSource, downloadDir string, paused bool) (string, *RPCResponseTorrent, bool) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	var metadata TorrentMetadata
+	if len(torrent.Metainfo) > 0 {
+		metadata, _ = parseMetainfo(torrent.Metainfo)
+
+		if part, err := writer.CreateFormFile("torrents", "metainfo.torrent"); err == nil {
+			_, _ = part.Write(torrent.Metainfo)
+		}
+	} else {
+		if strings.HasPrefix(torrent.Filename, "magnet:") {
+			metadata, _ = parseMagnet(torrent.Filename)
+		}
+
+		_ = writer.WriteField("urls", torrent.Filename)
+	}
+	if len(downloadDir) > 0 {
+		_ = writer.WriteField("savepath", downloadDir)
+	}
+	if paused {
+		_ = writer.WriteField("paused", "true")  // qBittorrent 4.x
+		_ = writer.WriteField("stopped", "true") // qBittorrent 5.x
+	}
+	_ = writer.Close()
+
+	res, _, err := c.send(ctx, "/torrents/add", body.String(), writer.FormDataContentType(), true)
+	if err != nil {
+		return fmt.Sprintf("Failed to add given torrent: %s", err), nil, false
+	}
+	if strings.TrimSpace(string(res)) != "Ok." {
+		return "Failed to add given torrent.", nil, false
+	}
+
+	// find the added torrent with its info hash
+	var added *RPCResponseTorrent
+	if len(metadata.InfoHash) > 0 {
+		for range numRetries {
+			var ts []qbittorrentTorrent
+			if err := c.getJSON(ctx, "/torrents/info", url.Values{"hashes": {metadata.InfoHash}}, &ts); err == nil && len(ts) > 0 {
+				t := c.convert(ts[0])
+				added = &t
+				break
+			}
+
+			// (torrents are added asynchronously)
+			select {
+			case <-ctx.Done():
+				return "Given torrent was successfully added to the list.", nil, true
+			case <-time.After(1 * time.Second):
+			}
+		}
+	}
+
+	return "Given torrent was successfully added to the list.", added, true
+}
+
+// RemoveTorrent removes a torrent.
+func (c *qbittorrentClient) RemoveTorrent(ctx context.Context, torrentID string, deleteLocal bool) (string, bool) {
+	hash, err := c.ids.key(torrentID)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	if _, err = c.sendForm(ctx, "/torrents/delete", url.Values{
+		"hashes":      {hash},
+		"deleteFiles": {fmt.Sprintf("%t", deleteLocal)},
+	}); err != nil {
+		return fmt.Sprintf("Failed to remove given torrent: %s", err), false
+	}
+
+	return removedMessage(torrentID, deleteLocal), true
+}
+
+// send a request for given torrent(s), trying the endpoints one by one
+//
+// (some endpoints were renamed in qBittorrent 5.x)
+func (c *qbittorrentClient) sendForTorrent(ctx context.Context, torrentID string, params url.Values, endpoints ...string) (err error) {
+	hashes := consts.ParamAllTorrents
+	if torrentID != consts.ParamAllTorrents {
+		if hashes, err = c.ids.key(torrentID); err != nil {
+			return err
+		}
+	}
+	params.Set("hashes", hashes)
+
+	for _, endpoint := range endpoints {
+		var status int
+		if _, status, err = c.send(ctx, endpoint, params.Encode(), "application/x-www-form-urlencoded", true); status != http.StatusNotFound {
+			break
+		}
+	}
+	return err
+}
+
+// PauseTorrent stops a torrent.
+func (c *qbittorrentClient) PauseTorrent(ctx context.Context, torrentID string) string {
+	if err := c.sendForTorrent(ctx, torrentID, url.Values{}, "/torrents/stop", "/torrents/pause"); err != nil {
+		return fmt.Sprintf("Failed to pause given torrent: %s", err)
+	}
+	return pausedMessage(torrentID)
+}
+
+// ResumeTorrent starts a torrent.
+//
+// When `now` is true, the torrent will be force-started (bypassing the queue).
+func (c *qbittorrentClient) ResumeTorrent(ctx context.Context, torrentID string, now bool) string {
+	var err error
+	if now {
+		err = c.sendForTorrent(ctx, torrentID, url.Values{"value": {"true"}}, "/torrents/setForceStart")
+	} else {
+		err = c.sendForTorrent(ctx, torrentID, url.Values{}, "/torrents/start", "/torrents/resume")
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to resume given torrent: %s", err)
+	}
+	return resumedMessage(torrentID)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// parse transmission queue command
+//
+// `/trqueue [@instance] [id] [top | up | down | bottom]`
+//
+// `/trqueue [@instance] size [number | off]`
+func parseTransmissionQueueCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt)
+	}
+	client, ok := transmissionClientFor(instance)
+	if !ok {
+		return consts.MessageNotSupportedByClient, nil
+	}
+	cmd := instanceCommand(instance, consts.CommandTransmissionQueue)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionQueue, "", 1)))
+
+	// show the queue
+	if len(params) <= 0 {
+		return transmissionQueue(ctx, config, instance, client, "")
+	}
+
+	// change queue size
+	if params[0] == consts.ParamQueueSize {
+		if len(params) < 2 {
+			return fmt.Sprintf("not a valid queue command: %s", txt), nil
+		}
+
+		arguments := map[string]any{}
+		if params[1] == consts.ParamOff {
+			arguments["download-queue-enabled"] = false
+		} else {
+			size, err := strconv.Atoi(params[1])
+			if err != nil || size <= 0 {
+				return fmt.Sprintf("not a valid queue size: %s", params[1]), nil
+			}
+			arguments["download-queue-size"] = size
+			arguments["download-queue-enabled"] = true
+		}
+
+		if err := client.SetSession(ctx, arguments); err != nil {
+			return fmt.Sprintf("Failed to change queue size: %s", err), nil
+		}
+		return transmissionQueue(ctx, config, instance, client, fmt.Sprintf("Applied: %s", strings.Join(params, " ")))
+	}
+
+	torrentID := params[0]
+
+	// move a torrent in the queue
+	var result string
+	if len(params) > 1 {
+		direction := params[1]
+		if !slices.Contains([]string{
+			consts.ParamQueueTop,
+			consts.ParamQueueUp,
+			consts.ParamQueueDown,
+			consts.ParamQueueBottom,
+		}, direction) {
+			return fmt.Sprintf("not a valid queue direction: %s", direction), nil
+		}
+
+		if err := client.MoveTorrentInQueue(ctx, torrentID, direction); err != nil {
+			return fmt.Sprintf("Failed to move torrent in the queue: %s", err), nil
+		}
+		result = fmt.Sprintf("Torrent id: %s was moved %s\n\n", torrentID, direction)
+	}
+
+	// show the position of a torrent with buttons for moving it
+	torrent, err := client.getTorrentWithFields(ctx, torrentID, torrentQueueFields)
+	if err != nil {
+		return err.Error(), nil
+	}
+	message = withInstanceName(config, instance, fmt.Sprintf("%s*%d*. _%s_\n  ┖ %s queue position: %d",
+		result,
+		torrent.ID,
+		removeMarkdownChars(torrent.Name, " "),
+		statusToString(torrent.Status),
+		torrent.QueuePosition+1,
+	))
+
+	move := func(text, direction string) bot.InlineKeyboardButton {
+		return bot.NewInlineKeyboardButton(text).
+			SetCallbackData(fmt.Sprintf("%s %s %s", cmd, torrentID, direction))
+	}
+	keyboards = [][]bot.InlineKeyboardButton{
+		{
+			move(consts.MessageQueueTop, consts.ParamQueueTop),
+			move(consts.MessageQueueUp, consts.ParamQueueUp),
+			move(consts.MessageQueueDown, consts.ParamQueueDown),
+			move(consts.MessageQueueBottom, consts.ParamQueueBottom),
+		},
+		{
+			bot.NewInlineKeyboardButton(consts.MessageQueueShow).
+				SetCallbackData(cmd),
+		},
+	}
+
+	return message, keyboards
+}
+
+// generate a message with queue settings and incomplete torrents in queue order,
+// with inline keyboards for selecting a torrent to move
+func transmissionQueue(
+	ctx context.Context,
+	config cfg.Config,
+	instance cfg.TransmissionInstance,
+	client *transmissionClient,
+	result string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	cmd := instanceCommand(instance, consts.CommandTransmissionQueue)
+
+	session, err := client.GetSession(ctx)
+	if err != nil {
+		return err.Error(), nil
+	}
+	torrents, err := client.GetTorrents(ctx)
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	// only incomplete torrents are in the download queue
+	queued := []RPCResponseTorrent{}
+	for _, t := range torrents {
+		if t.PercentDone < 1.0 {
+			queued = append(queued, t)
+		}
+	}
+	slices.SortFunc(queued, func(a, b RPCResponseTorrent) int {
+		return a.QueuePosition - b.QueuePosition
+	})
+
+	lines := []string{}
+	if len(result) > 0 {
+		lines = append(lines, result, "")
+	}
+	if session.DownloadQueueEnabled {
+		lines = append(lines, fmt.Sprintf("*download queue*: %d at once", session.DownloadQueueSize))
+	} else {
+		lines = append(lines, "*download queue*: off")
+	}
+	if len(queued) <= 0 {
+		lines = append(lines, consts.MessageTransmissionNoTorrents)
+	}
+	for i, t := range queued {
+		if i >= consts.NumQueuedTorrentsToShow {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(queued)-i))
+			break
+		}
+
+		lines = append(lines, fmt.Sprintf("%d. %s *%d*. _%s_ (%.2f%%)",
+			t.QueuePosition+1,
+			statusToString(t.Status),
+			t.ID,
+			removeMarkdownChars(t.Name, " "),
+			t.PercentDone*100.0,
+		))
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(fmt.Sprintf("%d. %s", t.QueuePosition+1, truncateString(t.Name, consts.MaxButtonTextLength))).
+				SetCallbackData(fmt.Sprintf("%s %d", cmd, t.ID)),
+		})
+	}
+	message = withInstanceName(config, instance, strings.Join(lines, "\n"))
+
+	// inline keyboards for queue size presets
+	size := func(text, size string) bot.InlineKeyboardButton {
+		return bot.NewInlineKeyboardButton(text).
+			SetCallbackData(fmt.Sprintf("%s %s %s", cmd, consts.ParamQueueSize, size))
+	}
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		size("1", "1"),
+		size("3", "3"),
+		size("5", "5"),
+		size("10", "10"),
+		size(consts.MessageQueueOff, consts.ParamOff),
+	}, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageRefresh).
+			SetCallbackData(cmd),
+	})
+
+	return message, keyboards
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// RSS 2.0, RSS 1.0 (RDF), or Atom document
+type feedDocument struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"` // RSS 2.0
+	Items   []rssItem   `xml:"item"`  // RSS 1.0
+	Entries []atomEntry `xml:"entry"` // Atom
+}
+
+// item of RSS document
+type rssItem struct {
+	Title      string   `xml:"title"`
+	Links      []string `xml:"link"`
+	GUID       string   `xml:"guid"`
+	Enclosures []struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	MagnetURI string `xml:"magnetURI"` // (eg. `torrent:magnetURI`)
+}
+
+// entry of Atom document
+type atomEntry struct {
+	Title string `xml:"title"`
+	ID    string `xml:"id"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+}
+
+// item of a feed
+type feedItem struct {
+	GUID  string
+	Title string
+	Link  string // magnet or url of .torrent file
+}
+
+// check if given url looks like a torrent
+func isTorrentLink(link, typ string) bool {
+	return typ == consts.MimeTypeTorrent ||
+		strings.HasPrefix(link, "magnet:") ||
+		strings.HasSuffix(strings.ToLower(link), ".torrent")
+}
+
+// parse given RSS/Atom document into items
+func parseFeed(bytes []byte) (items []feedItem, err error) {
+	var doc feedDocument
+	if err = xml.Unmarshal(bytes, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %s", err)
+	}
+
+	for _, item := range append(doc.Channel.Items, doc.Items...) {
+		link := ""
+		for _, enclosure := range item.Enclosures {
+			if isTorrentLink(enclosure.URL, enclosure.Type) {
+				link = enclosure.URL
+				break
+			}
+		}
+		if len(link) <= 0 && len(item.MagnetURI) > 0 {
+			link = item.MagnetURI
+		}
+		if len(link) <= 0 {
+			for _, l := range item.Links {
+				if l = strings.TrimSpace(l); len(l) > 0 {
+					link = l
+					break
+				}
+			}
+		}
+
+		guid := strings.TrimSpace(item.GUID)
+		if len(guid) <= 0 {
+			guid = link
+		}
+		items = append(items, feedItem{GUID: guid, Title: strings.TrimSpace(item.Title), Link: strings.TrimSpace(link)})
+	}
+
+	for _, entry := range doc.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if isTorrentLink(l.Href, l.Type) || l.Rel == "enclosure" {
+				link = l.Href
+				break
+			}
+		}
+		if len(link) <= 0 && len(entry.Links) > 0 {
+			link = entry.Links[0].Href
+		}
+
+		guid := strings.TrimSpace(entry.ID)
+		if len(guid) <= 0 {
+			guid = link
+		}
+		items = append(items, feedItem{GUID: guid, Title: strings.TrimSpace(entry.Title), Link: strings.TrimSpace(link)})
+	}
+
+	return items, nil
+}
+
+// fetch items of given feed
+func fetchFeed(ctx context.Context, feedURL string) (items []feedItem, err error) {
+	ctxFetch, cancelFetch := context.WithTimeout(ctx, consts.RSSFetchTimeoutSeconds*time.Second)
+	defer cancelFetch()
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctxFetch, "GET", feedURL, nil); err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed: HTTP %d", resp.StatusCode)
+	}
+
+	var bytes []byte
+	if bytes, err = io.ReadAll(io.LimitReader(resp.Body, consts.MaxRSSFeedSize+1)); err != nil {
+		return nil, err
+	}
+	if len(bytes) > consts.MaxRSSFeedSize {
+		return nil, fmt.Errorf("feed is too large: > %s", readableSize(consts.MaxRSSFeedSize))
+	}
+
+	return parseFeed(bytes)
+}
+
+// poll given feed and add torrents of new matching items,
+// and return the resulting messages
+//
+// (items of a feed which was never polled will only be remembered as seen, not downloaded)
+//
+// (items which failed to be added are not remembered as seen, and will be retried on next polls
+// until their numbers of failures in `failures` reach `consts.MaxRSSItemAttempts`)
+func pollFeed(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+	feed Feed,
+	failures map[string]int,
+) (messages []string, err error) {
+	var include, exclude *regexp.Regexp
+	if include, err = regexp.Compile(feed.Include); err != nil {
+		return nil, err
+	}
+	if exclude, err = regexp.Compile(feed.Exclude); err != nil {
+		return nil, err
+	}
+
+	var items []feedItem
+	if items, err = fetchFeed(ctx, feed.URL); err != nil {
+		return nil, err
+	}
+
+	firstPoll := feed.PolledAt.IsZero()
+	instance := instanceNamed(config, feed.Instance)
+
+	// (items are usually in reverse chronological order)
+	for _, item := range slices.Backward(items) {
+		if len(item.GUID) <= 0 || db.HasFeedItem(feed.ID, item.GUID) {
+			continue
+		}
+
+		if firstPoll || !include.MatchString(item.Title) || (len(feed.Exclude) > 0 && exclude.MatchString(item.Title)) {
+			db.SaveFeedItem(feed.ID, item.GUID, item.Title)
+			continue
+		}
+		if len(item.Link) <= 0 {
+			db.SaveFeedItem(feed.ID, item.GUID, item.Title)
+			messages = append(messages, fmt.Sprintf("⚠️ no torrent link in feed item: _%s_", removeMarkdownChars(item.Title, " ")))
+			continue
+		}
+
+		message, ok := addTorrentCheckingDiskUsage(ctx, client, config, instance, db, TorrentSource{Filename: item.Link}, feed.DownloadDir, false)
+		if !ok {
+			// retry it on next polls, until it fails too many times
+			if failures[item.GUID]++; failures[item.GUID] < consts.MaxRSSItemAttempts {
+				logError(db, "failed to add torrent of rss feed item '%s' (attempt %d/%d): %s", item.Title, failures[item.GUID], consts.MaxRSSItemAttempts, message)
+				continue
+			}
+		}
+		delete(failures, item.GUID)
+
+		db.SaveFeedItem(feed.ID, item.GUID, item.Title)
+		messages = append(messages, fmt.Sprintf("📰 _%s_\n  ┖ %s", removeMarkdownChars(item.Title, " "), message))
+	}
+
+	db.SetFeedPolled(feed.ID, time.Now())
+
+	return messages, nil
+}
+
+// run a loop which periodically polls feeds and adds torrents of new matching items
+func runRSSWatcher(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+) {
+	_stdout.Printf("starting rss watcher with interval: %d second(s)", config.RSS.Interval)
+
+	// sync feeds in the config
+	feeds := []Feed{}
+	for _, feed := range config.RSS.Feeds {
+		feeds = append(feeds, Feed{
+			URL:         feed.URL,
+			Include:     feed.Include,
+			Exclude:     feed.Exclude,
+			DownloadDir: feed.DownloadDir,
+			Instance:    feed.Instance,
+		})
+	}
+	db.SyncConfigFeeds(feeds)
+
+	ticker := time.NewTicker(time.Duration(config.RSS.Interval) * time.Second)
+	defer ticker.Stop()
+
+	lastErrs := map[uint]error{}
+	failures := map[uint]map[string]int{} // numbers of failures of items, by feed ids
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, feed := range db.GetFeeds() {
+				if feed.Paused {
+					continue
+				}
+
+				if failures[feed.ID] == nil {
+					failures[feed.ID] = map[string]int{}
+				}
+
+				messages, err := pollFeed(ctx, client, config, db, feed, failures[feed.ID])
+				if err != nil {
+					// log only when the error is a new one
+					if lastErr := lastErrs[feed.ID]; lastErr == nil || lastErr.Error() != err.Error() {
+						logError(db, "failed to poll rss feed '%s': %s", feed.URL, err)
+					}
+					lastErrs[feed.ID] = err
+
+					continue
+				}
+				delete(lastErrs, feed.ID)
+
+				if len(messages) > 0 {
+					broadcast(ctx, client, config, db, strings.Join(messages, "\n"))
+				}
+			}
+		}
+	}
+}
+
+// parse rss command
+//
+// `/rss [list]` for listing feeds,
+//
+// `/rss add [@instance] [url] [include:regex] [exclude:regex] [dir:location]` for adding a feed,
+//
+// `/rss [remove | pause | resume] [feed id]` for removing, pausing, or resuming a feed
+func parseRSSCommand(
+	config cfg.Config,
+	db *Database,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	if config.RSS == nil {
+		return consts.MessageNoRSS, nil
+	}
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandRSS, "", 1)))
+	if len(params) <= 0 || params[0] == consts.ParamRSSList {
+		return rssFeeds(config, db, "")
+	}
+
+	var result string
+	switch params[0] {
+	case consts.ParamRSSAdd:
+		var err error
+		if result, err = addFeed(config, db, txt); err != nil {
+			return err.Error(), nil
+		}
+	case consts.ParamRSSRemove, consts.ParamRSSPause, consts.ParamRSSResume:
+		if len(params) < 2 {
+			return fmt.Sprintf("not a valid rss command: %s", txt), nil
+		}
+		id, err := strconv.ParseUint(params[1], 10, 0)
+		if err != nil {
+			return fmt.Sprintf("not a valid feed id: %s", params[1]), nil
+		}
+		feed, err := db.GetFeed(uint(id))
+		if err != nil {
+			return fmt.Sprintf("no such feed: %s", params[1]), nil
+		}
+
+		switch params[0] {
+		case consts.ParamRSSRemove:
+			if feed.FromConfig {
+				return fmt.Sprintf("Feed *%d* is in the config, remove it from the config (or pause it) instead.", feed.ID), nil
+			}
+			err = db.DeleteFeed(feed.ID)
+			result = fmt.Sprintf("Removed feed: %s", removeMarkdownChars(feed.URL, " "))
+		case consts.ParamRSSPause:
+			err = db.SetFeedPaused(feed.ID, true)
+			result = fmt.Sprintf("Paused feed: %s", removeMarkdownChars(feed.URL, " "))
+		case consts.ParamRSSResume:
+			err = db.SetFeedPaused(feed.ID, false)
+			result = fmt.Sprintf("Resumed feed: %s", removeMarkdownChars(feed.URL, " "))
+		}
+		if err != nil {
+			return fmt.Sprintf("Failed to %s feed: %s", params[0], err), nil
+		}
+	default:
+		return fmt.Sprintf("not a valid rss command: %s", txt), nil
+	}
+
+	return rssFeeds(config, db, result)
+}
+
+// add a feed with given command text, and return the result message
+func addFeed(
+	config cfg.Config,
+	db *Database,
+	txt string,
+) (result string, err error) {
+	feed := Feed{}
+
+	// (`@instance` is parsed only as the first parameter, for `@`s in urls or filters)
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandRSS, "", 1)))[1:]
+	if len(params) > 0 {
+		if name, ok := strings.CutPrefix(params[0], "@"); ok {
+			instance, exists := config.TransmissionInstance(name)
+			if !exists {
+				return "", fmt.Errorf("no such transmission instance: %s", name)
+			}
+			feed.Instance = instance.Name
+			params = params[1:]
+		}
+	}
+
+	for _, param := range params {
+		switch {
+		case strings.HasPrefix(param, consts.ParamRSSIncludePrefix):
+			feed.Include = strings.TrimPrefix(param, consts.ParamRSSIncludePrefix)
+		case strings.HasPrefix(param, consts.ParamRSSExcludePrefix):
+			feed.Exclude = strings.TrimPrefix(param, consts.ParamRSSExcludePrefix)
+		case strings.HasPrefix(param, consts.ParamRSSLocationPrefix):
+			// name of download location, or an absolute path
+			location := strings.TrimPrefix(param, consts.ParamRSSLocationPrefix)
+			for _, l := range config.DownloadLocations {
+				if l.Name == location {
+					feed.DownloadDir = l.Path
+				}
+			}
+			if len(feed.DownloadDir) <= 0 {
+				if !filepath.IsAbs(location) {
+					return "", fmt.Errorf("no such download location: %s", location)
+				}
+				feed.DownloadDir = location
+			}
+		default:
+			feed.URL = param
+		}
+	}
+	if u, err := url.Parse(feed.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("not a valid url of rss feed: '%s'", feed.URL)
+	}
+	if _, err = regexp.Compile(feed.Include); err != nil {
+		return "", fmt.Errorf("failed to compile `include` of rss feed: %s", err)
+	}
+	if _, err = regexp.Compile(feed.Exclude); err != nil {
+		return "", fmt.Errorf("failed to compile `exclude` of rss feed: %s", err)
+	}
+
+	saved, err := db.SaveFeed(feed)
+	if err != nil {
+		return "", fmt.Errorf("failed to add feed: %s", err)
+	}
+
+	return fmt.Sprintf("Added feed *%d*: %s", saved.ID, removeMarkdownChars(saved.URL, " ")), nil
+}
+
+// generate a message with saved feeds,
+// with inline keyboards for pausing, resuming, or removing them
+func rssFeeds(
+	config cfg.Config,
+	db *Database,
+	result string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	lines := []string{}
+	if len(result) > 0 {
+		lines = append(lines, result, "")
+	}
+
+	feeds := db.GetFeeds()
+	if len(feeds) <= 0 {
+		lines = append(lines, consts.MessageNoRSSFeeds)
+	}
+	for _, feed := range feeds {
+		status := "polled: never"
+		if !feed.PolledAt.IsZero() {
+			status = fmt.Sprintf("polled: %s", feed.PolledAt.Format("2006-01-02 15:04:05"))
+		}
+		if feed.Paused {
+			status = "⏸ paused, " + status
+		}
+		if feed.FromConfig {
+			status = consts.MessageRSSConfigFeed + ", " + status
+		}
+
+		filters := []string{}
+		if len(feed.Include) > 0 {
+			filters = append(filters, fmt.Sprintf("include: `%s`", feed.Include))
+		}
+		if len(feed.Exclude) > 0 {
+			filters = append(filters, fmt.Sprintf("exclude: `%s`", feed.Exclude))
+		}
+		if len(feed.DownloadDir) > 0 {
+			filters = append(filters, fmt.Sprintf("dir: %s", removeMarkdownChars(feed.DownloadDir, " ")))
+		}
+
+		lines = append(lines, withInstanceName(config, instanceNamed(config, feed.Instance), fmt.Sprintf("*%d*. %s", feed.ID, removeMarkdownChars(feed.URL, " "))))
+		if len(filters) > 0 {
+			lines = append(lines, "  ┖ "+strings.Join(filters, ", "))
+		}
+		lines = append(lines, "  ┖ "+status)
+
+		button := func(text, param string) bot.InlineKeyboardButton {
+			return bot.NewInlineKeyboardButton(fmt.Sprintf("%s %d", text, feed.ID)).
+				SetCallbackData(fmt.Sprintf("%s %s %d", consts.CommandRSS, param, feed.ID))
+		}
+		row := []bot.InlineKeyboardButton{}
+		if feed.Paused {
+			row = append(row, button(consts.MessageRSSResume, consts.ParamRSSResume))
+		} else {
+			row = append(row, button(consts.MessageRSSPause, consts.ParamRSSPause))
+		}
+		if !feed.FromConfig { // (feeds in the config can only be removed from the config)
+			row = append(row, button(consts.MessageRSSRemove, consts.ParamRSSRemove).
+				SetStyle(bot.KeyboardStyleDanger))
+		}
+		keyboards = append(keyboards, row)
+	}
+	message = strings.Join(lines, "\n")
+
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageRefresh).
+			SetCallbackData(fmt.Sprintf("%s %s", consts.CommandRSS, consts.ParamRSSList)),
+	})
+
+	return message, keyboards
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// stalled torrent, with the instance it belongs to and its saved progress
+type stalledTorrent struct {
+	Instance cfg.TransmissionInstance
+	Torrent  RPCResponseTorrent
+	Progress TorrentProgress
+}
+
+// run a loop which periodically records progresses of torrents (for detecting stalled ones),
+// and notifies stalled ones to chats when `interval` of stalled policy is given
+func runStalledDetector(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+) {
+	interval := consts.StalledProgressIntervalSeconds
+	notify := config.Stalled != nil && config.Stalled.Interval > 0
+	if notify {
+		interval = config.Stalled.Interval
+	}
+
+	_stdout.Printf("starting stalled torrent detector with interval: %d second(s) (notification: %t)", interval, notify)
+
+	detect := func() {
+		stalled := []stalledTorrent{}
+		for _, instance := range config.TransmissionInstances {
+			detected, err := detectStalledTorrents(ctx, config, db, instance)
+			if err != nil {
+				logError(db, "failed to get torrents of '%s' for detecting stalled ones: %s", instance.Name, err)
+				continue
+			}
+
+			// notify only once
+			for _, s := range detected {
+				if !s.Progress.Notified {
+					stalled = append(stalled, s)
+				}
+			}
+		}
+
+		if notify && len(stalled) > 0 {
+			message, keyboards := stalledTorrentsMessage(config, stalled)
+			broadcastWithKeyboards(ctx, client, config, db, message, keyboards)
+
+			for _, s := range stalled {
+				db.SetTorrentProgressNotified(s.Instance.Name, s.Torrent.HashString)
+			}
+		}
+	}
+
+	detect()
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			detect()
+		}
+	}
+}
+
+// update saved progresses of downloading torrents of given instance, and return stalled ones
+func detectStalledTorrents(
+	ctx context.Context,
+	config cfg.Config,
+	db *Database,
+	instance cfg.TransmissionInstance,
+) (stalled []stalledTorrent, err error) {
+	torrents, err := torrentClientFor(instance).GetTorrents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// (paused or queued torrents are not tracked, so their progresses will start over when resumed)
+	downloading := []RPCResponseTorrent{}
+	for _, t := range torrents {
+		if t.Status == TorrentStatusDownloading && t.PercentDone < 1.0 {
+			downloading = append(downloading, t)
+		}
+	}
+	progresses := map[string]TorrentProgress{}
+	for _, p := range db.UpdateTorrentProgresses(instance.Name, downloading) {
+		progresses[p.HashString] = p
+	}
+
+	duration := time.Duration(config.StalledHours()) * time.Hour
+	for _, t := range downloading {
+		if p, exists := progresses[t.HashString]; exists && time.Since(p.ProgressedAt) >= duration {
+			stalled = append(stalled, stalledTorrent{
+				Instance: instance,
+				Torrent:  t,
+				Progress: p,
+			})
+		}
+	}
+
+	return stalled, nil
+}
+
+// parse transmission stalled command
+//
+// `/trstalled [@instance]`
+func parseTransmissionStalledCommand(
+	ctx context.Context,
+	config cfg.Config,
+	db *Database,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instances := config.TransmissionInstances
+	if instance, _, found := extractInstance(config, txt); found {
+		instances = []cfg.TransmissionInstance{instance}
+	}
+
+	lines := []string{}
+	stalled := []stalledTorrent{}
+	for _, instance := range instances {
+		detected, err := detectStalledTorrents(ctx, config, db, instance)
+		if err != nil {
+			lines = append(lines, withInstanceName(config, instance, err.Error()))
+			continue
+		}
+		stalled = append(stalled, detected...)
+	}
+
+	if len(stalled) <= 0 {
+		lines = append(lines, fmt.Sprintf("%s (no progress for %d hour(s))", consts.MessageNoStalledTorrents, config.StalledHours()))
+		return strings.Join(lines, "\n"), nil
+	}
+
+	message, keyboards = stalledTorrentsMessage(config, stalled)
+	if len(lines) > 0 {
+		message = strings.Join(lines, "\n") + "\n\n" + message
+	}
+
+	// refresh button
+	cmd := consts.CommandTransmissionStalled
+	if len(instances) == 1 && len(config.TransmissionInstances) > 1 {
+		cmd = instanceCommand(instances[0], cmd)
+	}
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageRefresh).
+			SetCallbackData(cmd),
+	})
+
+	return message, keyboards
+}
+
+// generate a message with given stalled torrents,
+// with inline keyboards for reannouncing, pausing, or removing them
+func stalledTorrentsMessage(
+	config cfg.Config,
+	stalled []stalledTorrent,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	lines := []string{fmt.Sprintf("*stalled torrents* (no progress for %d hour(s))", config.StalledHours())}
+	for i, s := range stalled {
+		if i >= consts.NumStalledTorrentsToShow {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(stalled)-i))
+			break
+		}
+
+		lines = append(lines, withInstanceName(config, s.Instance, fmt.Sprintf("*%d*. _%s_\n  ┖ stuck at %.2f%% for %s",
+			s.Torrent.ID,
+			removeMarkdownChars(s.Torrent.Name, " "),
+			s.Torrent.PercentDone*100.0,
+			time.Since(s.Progress.ProgressedAt).Truncate(time.Minute),
+		)))
+
+		button := func(text, cmd string) bot.InlineKeyboardButton {
+			return bot.NewInlineKeyboardButton(fmt.Sprintf("%s %d", text, s.Torrent.ID)).
+				SetCallbackData(fmt.Sprintf("%s %d", instanceCommand(s.Instance, cmd), s.Torrent.ID))
+		}
+		row := []bot.InlineKeyboardButton{}
+		if isTransmission(s.Instance) { // (reannouncing is supported only by transmission)
+			row = append(row, button(consts.MessageStalledReannounce, consts.CommandTransmissionReannounce))
+		}
+		row = append(row,
+			button(consts.MessageStalledPause, consts.CommandTransmissionPause),
+			button(consts.MessageStalledRemove, consts.CommandTransmissionRemove).
+				SetStyle(bot.KeyboardStyleDanger),
+		)
+		keyboards = append(keyboards, row)
+	}
+
+	return strings.Join(lines, "\n"), keyboards
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// run a loop which periodically records transfer volumes of transmission instances
+func runTransferStatsRecorder(
+	ctx context.Context,
+	config cfg.Config,
+	db *Database,
+) {
+	_stdout.Printf("starting transfer stats recorder with interval: %d second(s)", consts.TransferStatsIntervalSeconds)
+
+	record := func() {
+		for _, instance := range config.TransmissionInstances {
+			client, ok := transmissionClientFor(instance)
+			if !ok { // (session stats are supported only by transmission)
+				continue
+			}
+
+			if _, err := recordTransferStats(ctx, db, instance, client); err != nil {
+				logError(db, "failed to get session stats of '%s' for recording transfer volumes: %s", instance.Name, err)
+			}
+		}
+	}
+
+	record()
+
+	ticker := time.NewTicker(consts.TransferStatsIntervalSeconds * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			record()
+		}
+	}
+}
+
+// fetch session stats of given instance, and record its transfer volume of today
+func recordTransferStats(
+	ctx context.Context,
+	db *Database,
+	instance cfg.TransmissionInstance,
+	client *transmissionClient,
+) (stats RPCResponseSessionStats, err error) {
+	if stats, err = client.GetSessionStats(ctx); err != nil {
+		return stats, err
+	}
+
+	db.RecordTransferStats(
+		instance.Name,
+		time.Now().Format(consts.DateFormat),
+		stats.CumulativeStats.UploadedBytes,
+		stats.CumulativeStats.DownloadedBytes,
+	)
+
+	return stats, nil
+}
+
+// parse transmission stats command
+//
+// `/trstats [@instance]` for showing session stats and transfer volumes of today and this month,
+//
+// `/trstats [@instance] daily` for showing transfer volumes of recent days,
+//
+// `/trstats [@instance] monthly` for showing transfer volumes of recent months
+func parseTransmissionStatsCommand(
+	ctx context.Context,
+	config cfg.Config,
+	db *Database,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	instances := config.TransmissionInstances
+	cmd := consts.CommandTransmissionStats
+	if instance, rest, found := extractInstance(config, txt); found {
+		instances = []cfg.TransmissionInstance{instance}
+		txt = rest
+		if len(config.TransmissionInstances) > 1 {
+			cmd = instanceCommand(instance, cmd)
+		}
+	}
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionStats, "", 1)))
+
+	// (transfer volumes are summed over all given instances)
+	lines := []string{}
+	names := []string{}
+	stats := map[string]RPCResponseSessionStats{}
+	for _, instance := range instances {
+		client, ok := transmissionClientFor(instance)
+		if !ok {
+			if len(instances) == 1 {
+				return consts.MessageNotSupportedByClient, nil
+			}
+			continue
+		}
+		names = append(names, instance.Name)
+
+		// (record before showing, for up-to-date transfer volumes)
+		s, err := recordTransferStats(ctx, db, instance, client)
+		if err != nil {
+			lines = append(lines, withInstanceName(config, instance, err.Error()))
+			continue
+		}
+		stats[instance.Name] = s
+	}
+	if len(names) <= 0 {
+		return consts.MessageNotSupportedByClient, nil
+	}
+
+	now := time.Now()
+	mode := ""
+	if len(params) > 0 {
+		mode = params[0]
+	}
+	switch mode {
+	case consts.ParamStatsDaily:
+		since := now.AddDate(0, 0, -(consts.NumTransferStatsDays - 1)).Format(consts.DateFormat)
+		lines = append(lines, fmt.Sprintf("*daily transfers* (last %d days)", consts.NumTransferStatsDays))
+		lines = append(lines, transferVolumeLines(db.GetTransferVolumes(names, since, false))...)
+	case consts.ParamStatsMonthly:
+		firstDay := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		since := firstDay.AddDate(0, -(consts.NumTransferStatsMonths - 1), 0).Format(consts.DateFormat)
+		lines = append(lines, fmt.Sprintf("*monthly transfers* (last %d months)", consts.NumTransferStatsMonths))
+		lines = append(lines, transferVolumeLines(db.GetTransferVolumes(names, since, true))...)
+	case "":
+		for _, instance := range instances {
+			s, exists := stats[instance.Name]
+			if !exists {
+				continue
+			}
+
+			lines = append(lines, withInstanceName(config, instance, fmt.Sprintf(`*current session*
+  ┖ ↓%s ↑%s
+  ┖ active for %s
+*cumulative* (%d session(s))
+  ┖ ↓%s ↑%s
+  ┖ active for %s`,
+				readableSize(s.CurrentStats.DownloadedBytes),
+				readableSize(s.CurrentStats.UploadedBytes),
+				time.Duration(s.CurrentStats.SecondsActive)*time.Second,
+				s.CumulativeStats.SessionCount,
+				readableSize(s.CumulativeStats.DownloadedBytes),
+				readableSize(s.CumulativeStats.UploadedBytes),
+				time.Duration(s.CumulativeStats.SecondsActive)*time.Second,
+			)))
+		}
+
+		sum := func(volumes []TransferVolume) (total TransferVolume) {
+			for _, v := range volumes {
+				total.UploadedBytes += v.UploadedBytes
+				total.DownloadedBytes += v.DownloadedBytes
+			}
+			return total
+		}
+		today := sum(db.GetTransferVolumes(names, now.Format(consts.DateFormat), false))
+		thisMonth := sum(db.GetTransferVolumes(names, now.Format(consts.MonthFormat), true))
+		lines = append(lines,
+			"*transferred*",
+			fmt.Sprintf("  ┖ today: %s", readableTransferVolume(today)),
+			fmt.Sprintf("  ┖ this month: %s", readableTransferVolume(thisMonth)),
+		)
+	default:
+		return fmt.Sprintf("not a valid stats command: %s", txt), nil
+	}
+
+	keyboards = [][]bot.InlineKeyboardButton{
+		{
+			bot.NewInlineKeyboardButton(consts.MessageStatsDaily).
+				SetCallbackData(fmt.Sprintf("%s %s", cmd, consts.ParamStatsDaily)),
+			bot.NewInlineKeyboardButton(consts.MessageStatsMonthly).
+				SetCallbackData(fmt.Sprintf("%s %s", cmd, consts.ParamStatsMonthly)),
+			bot.NewInlineKeyboardButton(consts.MessageRefresh).
+				SetCallbackData(cmd),
+		},
+	}
+
+	return strings.Join(lines, "\n"), keyboards
+}
+
+// generate lines of given transfer volumes
+func transferVolumeLines(volumes []TransferVolume) (lines []string) {
+	if len(volumes) <= 0 {
+		return []string{consts.MessageNoTransferStats}
+	}
+
+	var total TransferVolume
+	for _, v := range volumes {
+		lines = append(lines, fmt.Sprintf("  ┖ %s: %s", v.Period, readableTransferVolume(v)))
+
+		total.UploadedBytes += v.UploadedBytes
+		total.DownloadedBytes += v.DownloadedBytes
+	}
+	lines = append(lines, fmt.Sprintf("  ┖ *total*: %s", readableTransferVolume(total)))
+
+	return lines
+}
+
+// convert given transfer volume to human-readable string
+func readableTransferVolume(volume TransferVolume) string {
+	return fmt.Sprintf("↓%s ↑%s (%s)",
+		readableSize(volume.DownloadedBytes),
+		readableSize(volume.UploadedBytes),
+		readableSize(volume.DownloadedBytes+volume.UploadedBytes),
+	)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// parse transmission trackers command, and return the command
+// which is waiting for an announce url (when it is not given)
+//
+// `/trtrackers [@instance] [id]` for showing trackers of a torrent,
+//
+// `/trtrackers [@instance] [id] add [url]` for adding a tracker,
+//
+// `/trtrackers [@instance] [id] remove [tracker id]` for removing a tracker,
+//
+// `/trtrackers [@instance] [id] replace [tracker id] [url]` for replacing the tracker across all torrents with its host,
+//
+// `/trtrackers [@instance] replace [host] [url]` for replacing trackers of given host across all torrents
+func parseTransmissionTrackersCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton, waitingURL string) {
+	instance, txt, found := extractInstance(config, txt)
+	if !found {
+		return consts.MessageTransmissionInstance, instanceKeyboards(config, txt), ""
+	}
+	client, ok := transmissionClientFor(instance)
+	if !ok {
+		return consts.MessageNotSupportedByClient, nil, ""
+	}
+	cmd := instanceCommand(instance, consts.CommandTransmissionTrackers)
+
+	params := strings.Fields(strings.TrimSpace(strings.Replace(txt, consts.CommandTransmissionTrackers, "", 1)))
+
+	// if no torrent is given, show a picker
+	if len(params) <= 0 {
+		torrents, err := client.GetTorrents(ctx)
+		if err != nil {
+			return err.Error(), nil, ""
+		}
+		if len(torrents) <= 0 {
+			return consts.MessageTransmissionNoTorrents, nil, ""
+		}
+
+		keys := map[string]string{}
+		for _, t := range torrents {
+			keys[fmt.Sprintf("%d. %s", t.ID, t.Name)] = fmt.Sprintf("%s %d", cmd, t.ID)
+		}
+		keyboards = bot.NewInlineKeyboardButtonsAsRowsWithCallbackData(keys)
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(consts.MessageCancel).
+				SetCallbackData(consts.CommandCancel).
+				SetStyle(bot.KeyboardStyleDanger),
+		})
+		return consts.MessageTransmissionTrackers, keyboards, ""
+	}
+
+	// replace trackers of a host across all torrents
+	if params[0] == consts.ParamTrackerReplace {
+		if len(params) < 3 {
+			return fmt.Sprintf("not a valid trackers command: %s", txt), nil, ""
+		}
+		return withInstanceName(config, instance, replaceTrackers(ctx, client, params[1], params[2])), nil, ""
+	}
+
+	torrentID := params[0]
+	torrent, err := client.GetTorrentTrackers(ctx, torrentID)
+	if err != nil {
+		return err.Error(), nil, ""
+	}
+
+	var result string
+	if len(params) > 1 {
+		action := params[1]
+		args := params[2:]
+
+		// tracker of given id
+		var tracker *RPCResponseTorrentTracker
+		if action == consts.ParamTrackerRemove || action == consts.ParamTrackerReplace {
+			if len(args) <= 0 {
+				return fmt.Sprintf("not a valid trackers command: %s", txt), nil, ""
+			}
+			if trackerID, err := strconv.Atoi(args[0]); err == nil {
+				for _, t := range torrent.TrackerStats {
+					if t.ID == trackerID {
+						tracker = &t
+						break
+					}
+				}
+			}
+			if tracker == nil {
+				return fmt.Sprintf("no such tracker: %s", args[0]), nil, ""
+			}
+			args = args[1:]
+		}
+
+		switch action {
+		case consts.ParamTrackerAdd:
+			if len(args) <= 0 {
+				return consts.MessageTransmissionTrackerURL, nil, fmt.Sprintf("%s %s %s", cmd, torrentID, action)
+			}
+			if !isValidAnnounceURL(args[0]) {
+				return fmt.Sprintf("not a valid announce url: %s", args[0]), nil, ""
+			}
+			if err := client.AddTracker(ctx, torrent, args[0]); err != nil {
+				return fmt.Sprintf("Failed to add tracker: %s", err), nil, ""
+			}
+			result = fmt.Sprintf("Added tracker: %s", removeMarkdownChars(args[0], " "))
+		case consts.ParamTrackerRemove:
+			if err := client.RemoveTracker(ctx, torrent, *tracker); err != nil {
+				return fmt.Sprintf("Failed to remove tracker: %s", err), nil, ""
+			}
+			result = fmt.Sprintf("Removed tracker: %s", removeMarkdownChars(tracker.Announce, " "))
+		case consts.ParamTrackerReplace:
+			if len(args) <= 0 {
+				return consts.MessageTransmissionTrackerURL, nil, fmt.Sprintf("%s %s %s %d", cmd, torrentID, action, tracker.ID)
+			}
+			result = replaceTrackers(ctx, client, announceHost(tracker.Announce), args[0])
+		default:
+			return fmt.Sprintf("not a valid trackers command: %s", txt), nil, ""
+		}
+
+		// reload trackers
+		if torrent, err = client.GetTorrentTrackers(ctx, torrentID); err != nil {
+			return err.Error(), nil, ""
+		}
+	}
+
+	message, keyboards = torrentTrackers(cmd, torrent, result)
+
+	return withInstanceName(config, instance, message), keyboards, ""
+}
+
+// generate a message with trackers of given torrent and their announce results,
+// with inline keyboards for managing them
+func torrentTrackers(
+	cmd string,
+	torrent RPCResponseTorrent,
+	result string,
+) (message string, keyboards [][]bot.InlineKeyboardButton) {
+	lines := []string{}
+	if len(result) > 0 {
+		lines = append(lines, result, "")
+	}
+	lines = append(lines, fmt.Sprintf("*%d*. _%s_", torrent.ID, removeMarkdownChars(torrent.Name, " ")))
+	if len(torrent.TrackerStats) <= 0 {
+		lines = append(lines, consts.MessageTransmissionNoTrackers)
+	}
+	for _, t := range torrent.TrackerStats {
+		announced := "never"
+		if t.LastAnnounceTime > 0 {
+			announced = time.Unix(t.LastAnnounceTime, 0).Format("2006-01-02 15:04:05")
+		}
+		succeeded := "✅"
+		if !t.LastAnnounceSucceeded {
+			succeeded = "⚠️"
+		}
+
+		lines = append(lines, fmt.Sprintf(`  ┖ [%d] %s
+      ┖ %s %s (%s)
+      ┖ seeders: %d, leechers: %d`,
+			t.ID,
+			removeMarkdownChars(t.Announce, " "),
+			succeeded,
+			removeMarkdownChars(t.LastAnnounceResult, " "),
+			announced,
+			t.SeederCount,
+			t.LeecherCount,
+		))
+
+		keyboards = append(keyboards, []bot.InlineKeyboardButton{
+			bot.NewInlineKeyboardButton(fmt.Sprintf("%s %s", consts.MessageTrackerRemove, truncateString(announceHost(t.Announce), consts.MaxButtonTextLength))).
+				SetCallbackData(fmt.Sprintf("%s %d %s %d", cmd, torrent.ID, consts.ParamTrackerRemove, t.ID)).
+				SetStyle(bot.KeyboardStyleDanger),
+			bot.NewInlineKeyboardButton(consts.MessageTrackerReplace).
+				SetCallbackData(fmt.Sprintf("%s %d %s %d", cmd, torrent.ID, consts.ParamTrackerReplace, t.ID)),
+		})
+	}
+	message = strings.Join(lines, "\n")
+
+	keyboards = append(keyboards, []bot.InlineKeyboardButton{
+		bot.NewInlineKeyboardButton(consts.MessageTrackerAdd).
+			SetCallbackData(fmt.Sprintf("%s %d %s", cmd, torrent.ID, consts.ParamTrackerAdd)).
+			SetStyle(bot.KeyboardStylePrimary),
+		bot.NewInlineKeyboardButton(consts.MessageRefresh).
+			SetCallbackData(fmt.Sprintf("%s %d", cmd, torrent.ID)),
+	})
+
+	return message, keyboards
+}
+
+// replace trackers of given host with the announce url across all torrents, and return the result message
+func replaceTrackers(
+	ctx context.Context,
+	client *transmissionClient,
+	host, announce string,
+) string {
+	if !isValidAnnounceURL(announce) {
+		return fmt.Sprintf("not a valid announce url: %s", announce)
+	}
+
+	torrents, err := client.GetTorrentsTrackers(ctx)
+	if err != nil {
+		return err.Error()
+	}
+
+	numReplaced := 0
+	errs := []string{}
+	for _, torrent := range torrents {
+		for _, tracker := range torrent.TrackerStats {
+			if !strings.EqualFold(announceHost(tracker.Announce), host) || tracker.Announce == announce {
+				continue
+			}
+
+			if err := client.ReplaceTracker(ctx, torrent, tracker, announce); err != nil {
+				errs = append(errs, fmt.Sprintf("*%d*: %s", torrent.ID, err))
+			} else {
+				numReplaced++
+			}
+			break // (only the first tracker of the host in each torrent)
+		}
+	}
+
+	lines := []string{fmt.Sprintf("Replaced trackers of %s with %s in %d torrent(s)", removeMarkdownChars(host, " "), removeMarkdownChars(announce, " "), numReplaced)}
+	if len(errs) > 0 {
+		lines = append(lines, fmt.Sprintf("Failed in %d torrent(s):", len(errs)))
+		lines = append(lines, errs...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// get the host name of given announce url
+func announceHost(announce string) string {
+	if u, err := url.Parse(announce); err == nil && len(u.Hostname()) > 0 {
+		return u.Hostname()
+	}
+	return announce
+}
+
+// check if given string is a valid announce url
+func isValidAnnounceURL(announce string) bool {
+	u, err := url.Parse(announce)
+	if err != nil || len(u.Hostname()) <= 0 {
+		return false
+	}
+
+	switch u.Scheme {
+	case "http", "https", "udp", "wss":
+		return true
+	}
+	return false
+}
@@ -2,15 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
 	"github.com/meinside/telegram-remotecontrol-bot/consts"
 )
 
@@ -35,8 +42,75 @@ type rpcResponse struct {
 
 // RPC response arguments
 type rpcResponseArgs struct {
-	TorrentDuplicate any                  `json:"torrent-duplicate,omitempty"`
+	TorrentAdded     *RPCResponseTorrent  `json:"torrent-added,omitempty"`
+	TorrentDuplicate *RPCResponseTorrent  `json:"torrent-duplicate,omitempty"`
 	Torrents         []RPCResponseTorrent `json:"torrents,omitempty"`
+
+	// for session-get
+	RPCResponseSession
+
+	// for session-stats
+	RPCResponseSessionStats
+
+	// for free-space
+	RPCResponseFreeSpace
+}
+
+// session fields to query
+var sessionFields []string = []string{
+	"speed-limit-down",
+	"speed-limit-down-enabled",
+	"speed-limit-up",
+	"speed-limit-up-enabled",
+	"alt-speed-enabled",
+	"alt-speed-down",
+	"alt-speed-up",
+	"download-dir",
+	"download-queue-enabled",
+	"download-queue-size",
+}
+
+// RPCResponseSession for session response
+type RPCResponseSession struct {
+	SpeedLimitDown        int64  `json:"speed-limit-down,omitempty"` // KB/s
+	SpeedLimitDownEnabled bool   `json:"speed-limit-down-enabled,omitempty"`
+	SpeedLimitUp          int64  `json:"speed-limit-up,omitempty"` // KB/s
+	SpeedLimitUpEnabled   bool   `json:"speed-limit-up-enabled,omitempty"`
+	AltSpeedEnabled       bool   `json:"alt-speed-enabled,omitempty"`
+	AltSpeedDown          int64  `json:"alt-speed-down,omitempty"` // KB/s
+	AltSpeedUp            int64  `json:"alt-speed-up,omitempty"`   // KB/s
+	DownloadDir           string `json:"download-dir,omitempty"`
+	DownloadQueueEnabled  bool   `json:"download-queue-enabled,omitempty"`
+	DownloadQueueSize     int    `json:"download-queue-size,omitempty"`
+	RPCVersion            int    `json:"rpc-version,omitempty"`
+}
+
+// RPCResponseFreeSpace for free space response
+type RPCResponseFreeSpace struct {
+	Path      string `json:"path,omitempty"`
+	SizeBytes int64  `json:"size-bytes,omitempty"` // available bytes
+	TotalSize int64  `json:"total_size,omitempty"` // total bytes (transmission 4.0+)
+}
+
+// RPCResponseSessionStats for session stats response
+type RPCResponseSessionStats struct {
+	DownloadSpeed      int64 `json:"downloadSpeed,omitempty"` // B/s
+	UploadSpeed        int64 `json:"uploadSpeed,omitempty"`   // B/s
+	ActiveTorrentCount int   `json:"activeTorrentCount,omitempty"`
+	PausedTorrentCount int   `json:"pausedTorrentCount,omitempty"`
+	TorrentCount       int   `json:"torrentCount,omitempty"`
+
+	CumulativeStats RPCResponseTransferStats `json:"cumulative-stats"` // (since transmission was installed)
+	CurrentStats    RPCResponseTransferStats `json:"current-stats"`    // (since transmission was launched)
+}
+
+// RPCResponseTransferStats for cumulative/current stats in session stats response
+type RPCResponseTransferStats struct {
+	UploadedBytes   int64 `json:"uploadedBytes"`
+	DownloadedBytes int64 `json:"downloadedBytes"`
+	FilesAdded      int64 `json:"filesAdded"`
+	SessionCount    int64 `json:"sessionCount"`
+	SecondsActive   int64 `json:"secondsActive"`
 }
 
 // torrent fields to query
@@ -49,18 +123,131 @@ var torrentFields []string = []string{
 	"percentDone",
 	"totalSize",
 	"errorString",
+	"hashString",
+	"addedDate",
+	"queuePosition",
+	"recheckProgress",
+}
+
+// torrent fields to query for details
+var torrentDetailFields []string = slices.Concat(
+	torrentFields,
+	[]string{
+		"files",
+		"peers",
+		"trackerStats",
+		"eta",
+		"uploadRatio",
+		"downloadDir",
+	},
+)
+
+// torrent fields to query for selecting files
+var torrentFileFields []string = []string{
+	"id",
+	"name",
+	"files",
+	"fileStats",
+}
+
+// torrent fields to query for managing the queue
+var torrentQueueFields []string = []string{
+	"id",
+	"name",
+	"status",
+	"queuePosition",
+}
+
+// torrent fields to query for managing trackers
+var torrentTrackerFields []string = []string{
+	"id",
+	"name",
+	"trackerStats",
+	"trackerList", // (rpc-version 17+)
 }
 
+// torrent fields to query for cleaning up
+var torrentCleanupFields []string = slices.Concat(
+	torrentFields,
+	[]string{
+		"uploadRatio",
+		"secondsSeeding",
+		"doneDate",
+	},
+)
+
+// torrent fields to query for exporting
+var torrentExportFields []string = slices.Concat(
+	torrentFields,
+	[]string{
+		"magnetLink",
+		"downloadDir",
+	},
+)
+
 // RPCResponseTorrent for torrent response
 type RPCResponseTorrent struct {
-	ID           int           `json:"id"`
-	Status       TorrentStatus `json:"status"`
-	Name         string        `json:"name"`
-	RateDownload int64         `json:"rateDownload"`
-	RateUpload   int64         `json:"rateUpload"`
-	PercentDone  float32       `json:"percentDone"`
-	TotalSize    int64         `json:"totalSize"`
-	Error        string        `json:"errorString"`
+	ID              int           `json:"id"`
+	Status          TorrentStatus `json:"status"`
+	Name            string        `json:"name"`
+	RateDownload    int64         `json:"rateDownload"`
+	RateUpload      int64         `json:"rateUpload"`
+	PercentDone     float32       `json:"percentDone"`
+	TotalSize       int64         `json:"totalSize"`
+	Error           string        `json:"errorString"`
+	HashString      string        `json:"hashString"`
+	AddedDate       int64         `json:"addedDate,omitempty"` // unix timestamp
+	QueuePosition   int           `json:"queuePosition"`
+	RecheckProgress float32       `json:"recheckProgress"`
+
+	// for details
+	Files          []RPCResponseTorrentFile     `json:"files,omitempty"`
+	FileStats      []RPCResponseTorrentFileStat `json:"fileStats,omitempty"`
+	Peers          []RPCResponseTorrentPeer     `json:"peers,omitempty"`
+	TrackerStats   []RPCResponseTorrentTracker  `json:"trackerStats,omitempty"`
+	ETA            int64                        `json:"eta,omitempty"`            // seconds (-1: not available, -2: unknown)
+	UploadRatio    float64                      `json:"uploadRatio,omitempty"`    // (-1: not available, -2: infinite)
+	DoneDate       int64                        `json:"doneDate,omitempty"`       // unix timestamp
+	SecondsSeeding int64                        `json:"secondsSeeding,omitempty"` // seconds
+	DownloadDir    string                       `json:"downloadDir,omitempty"`
+	TrackerList    string                       `json:"trackerList,omitempty"` // announce urls (one per line, tiers separated with blank lines)
+	MagnetLink     string                       `json:"magnetLink,omitempty"`
+}
+
+// RPCResponseTorrentFile for a file in torrent response
+type RPCResponseTorrentFile struct {
+	Name           string `json:"name"`
+	Length         int64  `json:"length"`
+	BytesCompleted int64  `json:"bytesCompleted"`
+}
+
+// RPCResponseTorrentFileStat for the stat of a file in torrent response
+type RPCResponseTorrentFileStat struct {
+	BytesCompleted int64        `json:"bytesCompleted"`
+	Wanted         bool         `json:"wanted"`
+	Priority       FilePriority `json:"priority"`
+}
+
+// RPCResponseTorrentPeer for a peer in torrent response
+type RPCResponseTorrentPeer struct {
+	Address      string  `json:"address"`
+	ClientName   string  `json:"clientName"`
+	Progress     float32 `json:"progress"`
+	RateToClient int64   `json:"rateToClient"` // B/s
+	RateToPeer   int64   `json:"rateToPeer"`   // B/s
+	FlagStr      string  `json:"flagStr"`
+}
+
+// RPCResponseTorrentTracker for a tracker stat in torrent response
+type RPCResponseTorrentTracker struct {
+	ID                    int    `json:"id"`
+	Host                  string `json:"host"`
+	Announce              string `json:"announce"`
+	LastAnnounceResult    string `json:"lastAnnounceResult"`
+	LastAnnounceSucceeded bool   `json:"lastAnnounceSucceeded"`
+	LastAnnounceTime      int64  `json:"lastAnnounceTime"` // unix timestamp
+	SeederCount           int    `json:"seederCount"`
+	LeecherCount          int    `json:"leecherCount"`
 }
 
 type TorrentStatus int
@@ -75,7 +262,13 @@ const (
 	TorrentStatusSeeding                 TorrentStatus = 6
 )
 
-var xTransmissionSessionID string = ""
+type FilePriority int
+
+const (
+	FilePriorityLow    FilePriority = -1
+	FilePriorityNormal FilePriority = 0
+	FilePriorityHigh   FilePriority = 1
+)
 
 // convert torrent status to string
 func statusToString(s TorrentStatus) string {
@@ -99,269 +292,899 @@ func statusToString(s TorrentStatus) string {
 	}
 }
 
-// generate a RPC url for local transmission server
-func getLocalTransmissionRPCURL(
-	port int,
-	username, passwd string,
-) string {
-	var rpcURL string
-	if len(username) > 0 && len(passwd) > 0 {
-		rpcURL = fmt.Sprintf("http://%s:%s@localhost:%d/transmission/rpc", url.QueryEscape(username), url.QueryEscape(passwd), port)
-	} else {
-		rpcURL = fmt.Sprintf("http://localhost:%d/transmission/rpc", port)
+// convert torrent status to its name
+func statusToName(s TorrentStatus) string {
+	switch s {
+	case TorrentStatusStopped:
+		return "stopped"
+	case TorrentStatusQueuedToVerifyLocalData:
+		return "queued to verify"
+	case TorrentStatusVerifyingLocalData:
+		return "verifying"
+	case TorrentStatusQueuedToDownload:
+		return "queued to download"
+	case TorrentStatusDownloading:
+		return "downloading"
+	case TorrentStatusQueuedToSeed:
+		return "queued to seed"
+	case TorrentStatusSeeding:
+		return "seeding"
+	default:
+		return "unknown"
 	}
-	return rpcURL
 }
 
-// POST to Transmission RPC server
+// convert file priority to string
+func priorityToString(p FilePriority) string {
+	switch p {
+	case FilePriorityLow:
+		return `🔽` // Low
+	case FilePriorityHigh:
+		return `🔼` // High
+	default:
+		return `⏺` // Normal
+	}
+}
+
+// errors of transmission RPC
+var (
+	ErrRPCUnauthorized = errors.New("unauthorized")        // wrong username or password
+	ErrRPCConflict     = errors.New("session id conflict") // session id could not be negotiated
+	ErrRPCUnreachable  = errors.New("unreachable")         // network errors (eg. daemon not running)
+
+	errSessionIDRenewed = errors.New("session id renewed") // (for retrying with the new session id)
+)
+
+// RPCResultError is returned when the result of RPC is not "success".
+type RPCResultError struct {
+	Method string
+	Result string
+}
+
+// Error returns the error message.
+func (e *RPCResultError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Method, e.Result)
+}
+
+// torrent client for transmission daemons
 //
-// https://trac.transmissionbt.com/browser/trunk/extras/rpc-spec.txt
-func post(
-	port int,
-	username, passwd string,
-	request rpcRequest,
-	numRetriesLeft int,
-) (res []byte, err error) {
-	if numRetriesLeft <= 0 {
-		return res, fmt.Errorf("no more retries for this request: %v", request)
+// https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md
+type transmissionClient struct {
+	rpc        cfg.TransmissionRPCEndpoint
+	httpClient *http.Client
+	err        error // error while creating http client
+
+	sessionID      string
+	sessionIDMutex sync.RWMutex
+
+	rpcVersion    int // `rpc-version` of the daemon (0 if not negotiated yet)
+	protocolMutex sync.Mutex
+	requestID     atomic.Int64 // (for JSON-RPC 2.0 requests)
+}
+
+// create a new transmission client
+func newTransmissionClient(rpc cfg.TransmissionRPCEndpoint) *transmissionClient {
+	c := &transmissionClient{
+		rpc: rpc,
 	}
+	c.httpClient, c.err = newHTTPClient(rpc)
 
-	var data []byte
-	if data, err = json.Marshal(request); err == nil {
-		var req *http.Request
-		if req, err = http.NewRequest("POST", getLocalTransmissionRPCURL(port, username, passwd), bytes.NewBuffer(data)); err == nil {
-			// headers
-			req.Header.Set(httpHeaderXTransmissionSessionID, xTransmissionSessionID)
+	return c
+}
+
+// get the current session id
+func (c *transmissionClient) getSessionID() string {
+	c.sessionIDMutex.RLock()
+	defer c.sessionIDMutex.RUnlock()
 
-			var resp *http.Response
-			client := &http.Client{}
-			if resp, err = client.Do(req); err == nil {
-				defer func() { _ = resp.Body.Close() }()
+	return c.sessionID
+}
 
-				if resp.StatusCode == http.StatusConflict {
-					if sessionID, exists := resp.Header[httpHeaderXTransmissionSessionID]; exists && len(sessionID) > 0 {
-						// update session id
-						xTransmissionSessionID = sessionID[0]
+// set the current session id
+func (c *transmissionClient) setSessionID(sessionID string) {
+	c.sessionIDMutex.Lock()
+	defer c.sessionIDMutex.Unlock()
 
-						return post(port, username, passwd, request, numRetriesLeft-1) // XXX - retry
-					}
+	c.sessionID = sessionID
+}
 
-					err = fmt.Errorf("couldn't find '%s' value from http headers", httpHeaderXTransmissionSessionID)
+// get the `rpc-version` of the daemon for negotiating the protocol (and features), if not done yet
+func (c *transmissionClient) getRPCVersion(ctx context.Context) (int, error) {
+	c.protocolMutex.Lock()
+	defer c.protocolMutex.Unlock()
 
-					log.Printf("error in RPC server: %s\n", err.Error())
-				}
+	if c.rpcVersion <= 0 {
+		// (legacy requests are understood by all daemons)
+		result, err := c.request(ctx, false, "session-get", map[string]any{
+			"fields": []string{"rpc-version"},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		c.rpcVersion = max(1, result.Arguments.RPCVersion)
+	}
 
-				res, _ = io.ReadAll(resp.Body)
+	return c.rpcVersion, nil
+}
 
-				if resp.StatusCode != http.StatusOK {
-					err = fmt.Errorf("HTTP %d (%s)", resp.StatusCode, string(res))
+// call a method of transmission RPC in the dialect of the daemon
+func (c *transmissionClient) call(
+	ctx context.Context,
+	method string,
+	arguments map[string]any,
+) (result rpcResponse, err error) {
+	if c.err != nil {
+		return result, c.err
+	}
 
-					log.Printf("error from RPC server: %s\n", err.Error())
-				} /* else {
-					// XXX - for debugging
-					log.Printf("returned json = %s\n", string(res))
-				}*/
-			} else {
-				log.Printf("error while sending request: %s\n", err.Error())
+	var rpcVersion int
+	if rpcVersion, err = c.getRPCVersion(ctx); err != nil {
+		return result, err
+	}
+
+	return c.request(ctx, rpcVersion >= minJSONRPCVersion, method, arguments)
+}
+
+// send a request of transmission RPC in given dialect, and decode its response
+func (c *transmissionClient) request(
+	ctx context.Context,
+	jsonRPC bool,
+	method string,
+	arguments map[string]any,
+) (result rpcResponse, err error) {
+	var data []byte
+	if jsonRPC {
+		data, err = encodeJSONRPCRequest(c.requestID.Add(1), method, arguments)
+	} else {
+		data, err = json.Marshal(rpcRequest{
+			Method:    method,
+			Arguments: arguments,
+		})
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal request: %s", err)
+	}
+
+	var res []byte
+	if res, err = c.postWithRetries(ctx, data); err != nil {
+		return result, err
+	}
 
-				return post(port, username, passwd, request, numRetriesLeft-1) // XXX - retry
+	if jsonRPC {
+		return decodeJSONRPCResponse(method, res)
+	}
+
+	if err = json.Unmarshal(res, &result); err != nil {
+		return result, fmt.Errorf("malformed RPC server response: %s", string(res))
+	}
+	if result.Result != "success" {
+		return result, &RPCResultError{Method: method, Result: result.Result}
+	}
+	return result, nil
+}
+
+// send a POST request to transmission RPC server, retrying with backoff on network errors
+func (c *transmissionClient) postWithRetries(
+	ctx context.Context,
+	data []byte,
+) (res []byte, err error) {
+	backoff := time.Duration(c.rpc.RetryInterval) * time.Millisecond
+	numRenewals, numRetriesOnError := 0, 0
+	for {
+		if res, err = c.post(ctx, data); err == nil {
+			return res, nil
+		}
+
+		switch {
+		case errors.Is(err, errSessionIDRenewed): // retry immediately with the new session id
+			if numRenewals++; numRenewals > numRetries {
+				return nil, fmt.Errorf("%w: session id was renewed too many times", ErrRPCConflict)
 			}
-		} else {
-			log.Printf("error while building request: %s\n", err.Error())
+		case errors.Is(err, ErrRPCUnreachable): // retry after a while
+			if numRetriesOnError++; numRetriesOnError > c.rpc.MaxRetries {
+				return nil, err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+				backoff *= 2
+			}
+		default:
+			return nil, err
 		}
-	} else {
-		log.Printf("error while marshaling data: %s\n", err.Error())
+	}
+}
+
+// send a POST request to transmission RPC server
+func (c *transmissionClient) post(
+	ctx context.Context,
+	data []byte,
+) (res []byte, err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.rpc.URL(), bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to build request: %s", err)
+	}
+	req.Header.Set(httpHeaderXTransmissionSessionID, c.getSessionID())
+	if len(c.rpc.Username) > 0 && len(c.rpc.Passwd) > 0 {
+		req.SetBasicAuth(c.rpc.Username, c.rpc.Passwd)
+	}
+
+	var resp *http.Response
+	if resp, err = c.httpClient.Do(req); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		log.Printf("error while sending request: %s\n", err.Error())
+
+		return nil, fmt.Errorf("%w: %s", ErrRPCUnreachable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusConflict:
+		if sessionID := resp.Header.Get(httpHeaderXTransmissionSessionID); len(sessionID) > 0 {
+			c.setSessionID(sessionID)
+
+			return nil, errSessionIDRenewed
+		}
+		return nil, fmt.Errorf("%w: couldn't find '%s' value from http headers", ErrRPCConflict, httpHeaderXTransmissionSessionID)
+	case http.StatusUnauthorized:
+		return nil, ErrRPCUnauthorized
+	}
+
+	res, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("HTTP %d (%s)", resp.StatusCode, string(res))
+
+		log.Printf("error from RPC server: %s\n", err.Error())
 	}
 
 	return res, err
 }
 
 // GetTorrents retrieves torrent objects.
-func GetTorrents(
-	port int,
-	username, passwd string,
+func (c *transmissionClient) GetTorrents(ctx context.Context) (torrents []RPCResponseTorrent, err error) {
+	return c.getTorrentsWithFields(ctx, torrentFields)
+}
+
+// retrieve torrent objects with given fields
+func (c *transmissionClient) getTorrentsWithFields(
+	ctx context.Context,
+	fields []string,
 ) (torrents []RPCResponseTorrent, err error) {
-	var output []byte
-	if output, err = post(
-		port,
-		username,
-		passwd,
-		rpcRequest{
-			Method: "torrent-get",
-			Arguments: map[string]any{
-				"fields": torrentFields,
-			},
-		},
-		numRetries,
-	); err == nil {
-		var result rpcResponse
-		if err = json.Unmarshal(output, &result); err == nil {
-			if result.Result == "success" {
-				torrents = result.Arguments.Torrents
-			} else {
-				err = fmt.Errorf("failed to list torrents")
-			}
-		}
+	var result rpcResponse
+	if result, err = c.call(ctx, "torrent-get", map[string]any{
+		"fields": fields,
+	}); err != nil {
+		return nil, err
 	}
-	return torrents, err
+	return result.Arguments.Torrents, nil
 }
 
-// GetList retrieves the list of transmission.
-func GetList(
-	port int,
-	username, passwd string,
-) string {
-	var torrents []RPCResponseTorrent
-	var err error
-	if torrents, err = GetTorrents(port, username, passwd); err == nil {
-		numTorrents := len(torrents)
-		if numTorrents > 0 {
-			lines := []string{}
-
-			for _, t := range torrents {
-				if len(t.Error) > 0 {
-					lines = append(lines, fmt.Sprintf(
-						`*%d*. _%s_
+// GetTorrent retrieves a torrent object with its details.
+func (c *transmissionClient) GetTorrent(
+	ctx context.Context,
+	torrentID string,
+) (torrent RPCResponseTorrent, err error) {
+	return c.getTorrentWithFields(ctx, torrentID, torrentDetailFields)
+}
+
+// GetTorrentFiles retrieves a torrent object with its files and their stats.
+func (c *transmissionClient) GetTorrentFiles(
+	ctx context.Context,
+	torrentID string,
+) (torrent RPCResponseTorrent, err error) {
+	return c.getTorrentWithFields(ctx, torrentID, torrentFileFields)
+}
+
+// retrieve a torrent object with given fields
+func (c *transmissionClient) getTorrentWithFields(
+	ctx context.Context,
+	torrentID string,
+	fields []string,
+) (torrent RPCResponseTorrent, err error) {
+	var numID int
+	if numID, err = strconv.Atoi(torrentID); err != nil {
+		return torrent, fmt.Errorf("not a valid torrent id: %s", torrentID)
+	}
+
+	var result rpcResponse
+	if result, err = c.call(ctx, "torrent-get", map[string]any{
+		"ids":    []int{numID},
+		"fields": fields,
+	}); err != nil {
+		return torrent, err
+	}
+	if len(result.Arguments.Torrents) <= 0 {
+		return torrent, fmt.Errorf("no such torrent: %s", torrentID)
+	}
+	return result.Arguments.Torrents[0], nil
+}
+
+// generate an entry of torrent list for given torrent
+func torrentListEntry(t RPCResponseTorrent) string {
+	if len(t.Error) > 0 {
+		return fmt.Sprintf(
+			`*%d*. _%s_
   ┖ (%s) *%s*`,
-						t.ID,
-						removeMarkdownChars(t.Name, " "),
-						readableSize(t.TotalSize),
-						t.Error,
-					))
-				} else {
-					details := []string{}
-
-					switch t.Status {
-					case TorrentStatusSeeding:
-						details = append(
-							details,
-							fmt.Sprintf(
-								"%s %s",
-								statusToString(t.Status),
-								readableSize(t.TotalSize),
-							),
-						)
-						if t.RateUpload > 0 {
-							details = append(details, fmt.Sprintf("↑%s/s", readableSize(t.RateUpload)))
-						}
-					case TorrentStatusDownloading, TorrentStatusStopped:
-						details = append(
-							details,
-							fmt.Sprintf(
-								"%s %s/%s (%.2f%%)",
-								statusToString(t.Status),
-								readableSize(int64(float64(t.TotalSize)*float64(t.PercentDone))),
-								readableSize(t.TotalSize),
-								t.PercentDone*100.0,
-							),
-						)
-						updown := []string{}
-						if t.RateDownload > 0 {
-							updown = append(updown, fmt.Sprintf("↓%s/s", readableSize(t.RateDownload)))
-						}
-						if t.RateUpload > 0 {
-							updown = append(updown, fmt.Sprintf("↑%s/s", readableSize(t.RateUpload)))
-						}
-						if len(updown) > 0 {
-							details = append(details, strings.Join(updown, " "))
-						}
-					default:
-						details = append(
-							details,
-							statusToString(t.Status),
-						)
-					}
-					// prepend spaces to details
-					for i := range details {
-						details[i] = `  ┖ ` + details[i]
-					}
-
-					lines = append(lines, fmt.Sprintf(
-						`*%d*. _%s_
+			t.ID,
+			removeMarkdownChars(t.Name, " "),
+			readableSize(t.TotalSize),
+			t.Error,
+		)
+	}
+
+	details := []string{}
+
+	switch t.Status {
+	case TorrentStatusSeeding:
+		details = append(
+			details,
+			fmt.Sprintf(
+				"%s %s",
+				statusToString(t.Status),
+				readableSize(t.TotalSize),
+			),
+		)
+		if t.RateUpload > 0 {
+			details = append(details, fmt.Sprintf("↑%s/s", readableSize(t.RateUpload)))
+		}
+	case TorrentStatusDownloading, TorrentStatusStopped:
+		details = append(
+			details,
+			fmt.Sprintf(
+				"%s %s/%s (%.2f%%)",
+				statusToString(t.Status),
+				readableSize(int64(float64(t.TotalSize)*float64(t.PercentDone))),
+				readableSize(t.TotalSize),
+				t.PercentDone*100.0,
+			),
+		)
+		updown := []string{}
+		if t.RateDownload > 0 {
+			updown = append(updown, fmt.Sprintf("↓%s/s", readableSize(t.RateDownload)))
+		}
+		if t.RateUpload > 0 {
+			updown = append(updown, fmt.Sprintf("↑%s/s", readableSize(t.RateUpload)))
+		}
+		if len(updown) > 0 {
+			details = append(details, strings.Join(updown, " "))
+		}
+	default:
+		details = append(
+			details,
+			statusToString(t.Status),
+		)
+	}
+	// prepend spaces to details
+	for i := range details {
+		details[i] = `  ┖ ` + details[i]
+	}
+
+	return fmt.Sprintf(
+		`*%d*. _%s_
 %s`,
-						t.ID,
-						removeMarkdownChars(t.Name, " "),
-						strings.Join(details, "\n"),
-					))
-				}
-			}
-			lines = append(lines, `----`)
-			lines = append(lines, fmt.Sprintf("total %d torrent(s)", numTorrents))
+		t.ID,
+		removeMarkdownChars(t.Name, " "),
+		strings.Join(details, "\n"),
+	)
+}
 
-			return strings.Join(lines, "\n")
+// GetInfo retrieves the details of a torrent as lines of multiple sections.
+func GetInfo(
+	ctx context.Context,
+	client TorrentClient,
+	torrentID string,
+) []string {
+	torrent, err := client.GetTorrent(ctx, torrentID)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	lines := []string{
+		fmt.Sprintf("*%d*. _%s_", torrent.ID, removeMarkdownChars(torrent.Name, " ")),
+		fmt.Sprintf("  ┖ status: %s", statusToString(torrent.Status)),
+		fmt.Sprintf("  ┖ progress: %s/%s (%.2f%%)",
+			readableSize(int64(float64(torrent.TotalSize)*float64(torrent.PercentDone))),
+			readableSize(torrent.TotalSize),
+			torrent.PercentDone*100.0,
+		),
+		fmt.Sprintf("  ┖ rate: ↓%s/s ↑%s/s", readableSize(torrent.RateDownload), readableSize(torrent.RateUpload)),
+		fmt.Sprintf("  ┖ eta: %s", readableETA(torrent.ETA)),
+		fmt.Sprintf("  ┖ ratio: %s", readableRatio(torrent.UploadRatio)),
+		fmt.Sprintf("  ┖ added: %s", time.Unix(torrent.AddedDate, 0).Format("2006-01-02 15:04:05")),
+		fmt.Sprintf("  ┖ directory: %s", removeMarkdownChars(torrent.DownloadDir, " ")),
+		fmt.Sprintf("  ┖ hash: `%s`", torrent.HashString),
+	}
+	if _, ok := client.(*transmissionClient); ok {
+		lines = append(lines, fmt.Sprintf("  ┖ queue position: %d", torrent.QueuePosition+1))
+	}
+	if len(torrent.Error) > 0 {
+		lines = append(lines, fmt.Sprintf("  ┖ error: *%s*", removeMarkdownChars(torrent.Error, " ")))
+	}
+
+	// files
+	lines = append(lines, "", fmt.Sprintf("*files* (%d)", len(torrent.Files)))
+	for i, f := range torrent.Files {
+		var percent float64
+		if f.Length > 0 {
+			percent = float64(f.BytesCompleted) / float64(f.Length) * 100.0
 		}
+		lines = append(lines, fmt.Sprintf("  %d. %s (%s, %.1f%%)",
+			i+1,
+			removeMarkdownChars(f.Name, " "),
+			readableSize(f.Length),
+			percent,
+		))
+	}
+
+	// peers
+	lines = append(lines, "", fmt.Sprintf("*peers* (%d)", len(torrent.Peers)))
+	for _, p := range torrent.Peers {
+		lines = append(lines, fmt.Sprintf("  ┖ %s (%s) %.1f%% ↓%s/s ↑%s/s",
+			p.Address,
+			removeMarkdownChars(p.ClientName, " "),
+			p.Progress*100.0,
+			readableSize(p.RateToClient),
+			readableSize(p.RateToPeer),
+		))
+	}
 
-		return consts.MessageTransmissionNoTorrents
+	// trackers
+	lines = append(lines, "", fmt.Sprintf("*trackers* (%d)", len(torrent.TrackerStats)))
+	for _, t := range torrent.TrackerStats {
+		result := t.LastAnnounceResult
+		if len(result) <= 0 {
+			result = "-"
+		}
+		lines = append(lines, fmt.Sprintf("  ┖ %s: %s (seeders: %d, leechers: %d)",
+			removeMarkdownChars(t.Host, " "),
+			removeMarkdownChars(result, " "),
+			t.SeederCount,
+			t.LeecherCount,
+		))
 	}
 
-	return err.Error()
+	return lines
 }
 
-// AddTorrent adds a torrent(with magnet or .torrent file) to the list of transmission
-// and returns the resulting string.
-func AddTorrent(port int, username, passwd, torrent string) string {
-	var output []byte
-	var err error
-	if output, err = post(port, username, passwd, rpcRequest{
-		Method: "torrent-add",
-		Arguments: map[string]any{
-			"filename": torrent,
-		},
-	}, numRetries); err == nil {
-		var result rpcResponse
-		if err = json.Unmarshal(output, &result); err == nil {
-			if result.Result == "success" {
-				if result.Arguments.TorrentDuplicate != nil {
-					return "Duplicated torrent was given."
-				}
+// TorrentSource is a source of torrent to add
+type TorrentSource struct {
+	Filename string // magnet or url
+	Metainfo []byte // contents of .torrent file
+}
 
-				return "Given torrent was successfully added to the list."
-			}
+// AddTorrent adds a torrent(with magnet, url, or contents of .torrent file) to the list of transmission
+// and returns the resulting string, along with the added torrent (if successful).
+//
+// When `downloadDir` is empty, transmission's default download directory will be used.
+// When `paused` is true, the torrent will be added without being started.
+func (c *transmissionClient) AddTorrent(
+	ctx context.Context,
+	torrent TorrentSource,
+	downloadDir string,
+	paused bool,
+) (string, *RPCResponseTorrent, bool) {
+	arguments := map[string]any{}
+	if len(torrent.Metainfo) > 0 {
+		arguments["metainfo"] = base64.StdEncoding.EncodeToString(torrent.Metainfo)
+	} else {
+		arguments["filename"] = torrent.Filename
+	}
+	if len(downloadDir) > 0 {
+		arguments["download-dir"] = downloadDir
+	}
+	if paused {
+		arguments["paused"] = true
+	}
 
-			return "Failed to add given torrent."
+	result, err := c.call(ctx, "torrent-add", arguments)
+	if err != nil {
+		return fmt.Sprintf("Failed to add given torrent: %s", err), nil, false
+	}
+	if result.Arguments.TorrentDuplicate != nil {
+		return "Duplicated torrent was given.", nil, false
+	}
+
+	return "Given torrent was successfully added to the list.", result.Arguments.TorrentAdded, true
+}
+
+// RemoveTorrent removes a torrent from the list (and its local data when `deleteLocal` is true).
+func (c *transmissionClient) RemoveTorrent(
+	ctx context.Context,
+	torrentID string,
+	deleteLocal bool,
+) (string, bool) {
+	numID, err := strconv.Atoi(torrentID)
+	if err != nil {
+		return fmt.Sprintf("not a valid torrent id: %s", torrentID), false
+	}
+
+	if _, err = c.call(ctx, "torrent-remove", map[string]any{
+		"ids":               []int{numID},
+		"delete-local-data": deleteLocal,
+	}); err != nil {
+		return fmt.Sprintf("Failed to remove given torrent: %s", err), false
+	}
+
+	return removedMessage(torrentID, deleteLocal), true
+}
+
+// start/stop torrent(s) with given method
+//
+// (torrentID == "all" for all torrents)
+func (c *transmissionClient) startStopTorrent(
+	ctx context.Context,
+	method string,
+	torrentID string,
+) (err error) {
+	arguments := map[string]any{}
+	if torrentID != consts.ParamAllTorrents {
+		var numID int
+		if numID, err = strconv.Atoi(torrentID); err != nil {
+			return fmt.Errorf("not a valid torrent id: %s", torrentID)
 		}
+		arguments["ids"] = []int{numID}
+	}
+
+	_, err = c.call(ctx, method, arguments)
 
-		return fmt.Sprintf("Malformed RPC server response: %s", string(output))
+	return err
+}
+
+// PauseTorrent stops a torrent (or all torrents with "all").
+func (c *transmissionClient) PauseTorrent(
+	ctx context.Context,
+	torrentID string,
+) string {
+	if err := c.startStopTorrent(ctx, "torrent-stop", torrentID); err != nil {
+		return fmt.Sprintf("Failed to pause given torrent: %s", err)
 	}
 
-	return fmt.Sprintf("Failed to add given torrent: %s", err)
+	return pausedMessage(torrentID)
 }
 
-// remove torrent
-func removeTorrent(
-	port int,
-	username, passwd string,
-	torrentID string, deleteLocal bool,
+// ResumeTorrent starts a torrent (or all torrents with "all").
+//
+// When `now` is true, the torrent will be started bypassing the download queue.
+func (c *transmissionClient) ResumeTorrent(
+	ctx context.Context,
+	torrentID string,
+	now bool,
 ) string {
-	if numID, err := strconv.Atoi(torrentID); err == nil {
-		if output, err := post(port, username, passwd,
-			rpcRequest{
-				Method: "torrent-remove",
-				Arguments: map[string]any{
-					"ids":               []int{numID},
-					"delete-local-data": deleteLocal,
-				},
-			}, numRetries); err == nil {
-			var result rpcResponse
-			if err := json.Unmarshal(output, &result); err == nil {
-				if result.Result == "success" {
-					if deleteLocal {
-						return fmt.Sprintf("Torrent id: %s and its data were successfully deleted", torrentID)
-					}
-
-					return fmt.Sprintf("Torrent id: %s was successfully removed from the list", torrentID)
-				}
+	method := "torrent-start"
+	if now {
+		method = "torrent-start-now"
+	}
 
-				return "Failed to remove given torrent."
-			}
+	if err := c.startStopTorrent(ctx, method, torrentID); err != nil {
+		return fmt.Sprintf("Failed to resume given torrent: %s", err)
+	}
+
+	return resumedMessage(torrentID)
+}
 
-			return fmt.Sprintf("Malformed RPC server response: %s", string(output))
+// MoveTorrentInQueue moves a torrent in the queue.
+//
+// (`direction`: `top`, `up`, `down`, or `bottom`)
+func (c *transmissionClient) MoveTorrentInQueue(
+	ctx context.Context,
+	torrentID string,
+	direction string,
+) (err error) {
+	var numID int
+	if numID, err = strconv.Atoi(torrentID); err != nil {
+		return fmt.Errorf("not a valid torrent id: %s", torrentID)
+	}
+
+	_, err = c.call(ctx, "queue-move-"+direction, map[string]any{
+		"ids": []int{numID},
+	})
+
+	return err
+}
+
+// call a method with given ids of torrents
+func (c *transmissionClient) callWithIDs(
+	ctx context.Context,
+	method string,
+	torrentIDs []int,
+	arguments map[string]any,
+) (err error) {
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	arguments["ids"] = torrentIDs
+
+	_, err = c.call(ctx, method, arguments)
+
+	return err
+}
+
+// VerifyTorrents starts verifying local data of torrents.
+func (c *transmissionClient) VerifyTorrents(
+	ctx context.Context,
+	torrentIDs []int,
+) error {
+	return c.callWithIDs(ctx, "torrent-verify", torrentIDs, nil)
+}
+
+// ReannounceTorrents asks trackers for more peers of torrents.
+func (c *transmissionClient) ReannounceTorrents(
+	ctx context.Context,
+	torrentIDs []int,
+) error {
+	return c.callWithIDs(ctx, "torrent-reannounce", torrentIDs, nil)
+}
+
+// SetTorrentsLocation changes the location of torrents.
+//
+// When `move` is true, local data will be moved to the new location,
+// otherwise it will be looked for in the new location.
+func (c *transmissionClient) SetTorrentsLocation(
+	ctx context.Context,
+	torrentIDs []int,
+	location string,
+	move bool,
+) error {
+	return c.callWithIDs(ctx, "torrent-set-location", torrentIDs, map[string]any{
+		"location": location,
+		"move":     move,
+	})
+}
+
+// minimum `rpc-version` of daemons which support `trackerList`
+// (`trackerAdd`, `trackerRemove`, and `trackerReplace` are deprecated since then)
+const minTrackerListRPCVersion = 17
+
+// GetTorrentsForExport retrieves all torrent objects with their magnet links and download directories.
+func (c *transmissionClient) GetTorrentsForExport(ctx context.Context) (torrents []RPCResponseTorrent, err error) {
+	return c.getTorrentsWithFields(ctx, torrentExportFields)
+}
+
+// GetTorrentsTrackers retrieves all torrent objects with their trackers.
+func (c *transmissionClient) GetTorrentsTrackers(ctx context.Context) (torrents []RPCResponseTorrent, err error) {
+	return c.getTorrentsWithFields(ctx, torrentTrackerFields)
+}
+
+// GetTorrentTrackers retrieves a torrent object with its trackers.
+func (c *transmissionClient) GetTorrentTrackers(
+	ctx context.Context,
+	torrentID string,
+) (torrent RPCResponseTorrent, err error) {
+	return c.getTorrentWithFields(ctx, torrentID, torrentTrackerFields)
+}
+
+// modify trackers of a torrent with `trackerList` (rpc-version 17+), or with given legacy arguments
+func (c *transmissionClient) modifyTrackers(
+	ctx context.Context,
+	torrent RPCResponseTorrent,
+	modify func(tiers [][]string) [][]string,
+	legacyArguments map[string]any,
+) error {
+	rpcVersion, err := c.getRPCVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	torrentID := strconv.Itoa(torrent.ID)
+	if rpcVersion < minTrackerListRPCVersion {
+		return c.setTorrent(ctx, torrentID, legacyArguments)
+	}
+
+	// parse tiers of announce urls
+	tiers := [][]string{}
+	for tier := range strings.SplitSeq(strings.ReplaceAll(torrent.TrackerList, "\r\n", "\n"), "\n\n") {
+		if urls := strings.Fields(tier); len(urls) > 0 {
+			tiers = append(tiers, urls)
 		}
+	}
+
+	lines := []string{}
+	for _, urls := range modify(tiers) {
+		if len(urls) > 0 {
+			lines = append(lines, strings.Join(urls, "\n"))
+		}
+	}
+
+	return c.setTorrent(ctx, torrentID, map[string]any{
+		"trackerList": strings.Join(lines, "\n\n"),
+	})
+}
+
+// AddTracker adds a tracker (as a new tier) to a torrent.
+//
+// (`torrent` should be retrieved with `GetTorrentTrackers`)
+func (c *transmissionClient) AddTracker(
+	ctx context.Context,
+	torrent RPCResponseTorrent,
+	announce string,
+) error {
+	return c.modifyTrackers(ctx, torrent, func(tiers [][]string) [][]string {
+		return append(tiers, []string{announce})
+	}, map[string]any{
+		"trackerAdd": []string{announce},
+	})
+}
+
+// RemoveTracker removes a tracker from a torrent.
+//
+// (`torrent` should be retrieved with `GetTorrentTrackers`)
+func (c *transmissionClient) RemoveTracker(
+	ctx context.Context,
+	torrent RPCResponseTorrent,
+	tracker RPCResponseTorrentTracker,
+) error {
+	return c.modifyTrackers(ctx, torrent, func(tiers [][]string) [][]string {
+		for i, urls := range tiers {
+			tiers[i] = slices.DeleteFunc(urls, func(url string) bool {
+				return url == tracker.Announce
+			})
+		}
+		return tiers
+	}, map[string]any{
+		"trackerRemove": []int{tracker.ID},
+	})
+}
+
+// ReplaceTracker replaces the announce url of a tracker of a torrent.
+//
+// (`torrent` should be retrieved with `GetTorrentTrackers`)
+func (c *transmissionClient) ReplaceTracker(
+	ctx context.Context,
+	torrent RPCResponseTorrent,
+	tracker RPCResponseTorrentTracker,
+	announce string,
+) error {
+	return c.modifyTrackers(ctx, torrent, func(tiers [][]string) [][]string {
+		for _, urls := range tiers {
+			for j, url := range urls {
+				if url == tracker.Announce {
+					urls[j] = announce
+				}
+			}
+		}
+		return tiers
+	}, map[string]any{
+		"trackerReplace": []any{tracker.ID, announce},
+	})
+}
+
+// set properties of a torrent with given arguments
+func (c *transmissionClient) setTorrent(
+	ctx context.Context,
+	torrentID string,
+	arguments map[string]any,
+) (err error) {
+	var numID int
+	if numID, err = strconv.Atoi(torrentID); err != nil {
+		return fmt.Errorf("not a valid torrent id: %s", torrentID)
+	}
+	arguments["ids"] = []int{numID}
+
+	_, err = c.call(ctx, "torrent-set", arguments)
+
+	return err
+}
+
+// SetFilesWanted marks files (with given indices) of a torrent as wanted or unwanted.
+func (c *transmissionClient) SetFilesWanted(
+	ctx context.Context,
+	torrentID string,
+	fileIndices []int,
+	wanted bool,
+) error {
+	key := "files-unwanted"
+	if wanted {
+		key = "files-wanted"
+	}
+
+	return c.setTorrent(ctx, torrentID, map[string]any{
+		key: fileIndices,
+	})
+}
 
-		return fmt.Sprintf("Failed to remove given torrent: %s", err)
+// SetFilesPriority sets the priority of files (with given indices) of a torrent.
+func (c *transmissionClient) SetFilesPriority(
+	ctx context.Context,
+	torrentID string,
+	fileIndices []int,
+	priority FilePriority,
+) error {
+	var key string
+	switch priority {
+	case FilePriorityLow:
+		key = "priority-low"
+	case FilePriorityHigh:
+		key = "priority-high"
+	default:
+		key = "priority-normal"
 	}
 
-	return fmt.Sprintf("not a valid torrent id: %s", torrentID)
+	return c.setTorrent(ctx, torrentID, map[string]any{
+		key: fileIndices,
+	})
+}
+
+// GetSession retrieves the session of transmission.
+func (c *transmissionClient) GetSession(ctx context.Context) (session RPCResponseSession, err error) {
+	var result rpcResponse
+	if result, err = c.call(ctx, "session-get", map[string]any{
+		"fields": sessionFields,
+	}); err != nil {
+		return session, err
+	}
+	return result.Arguments.RPCResponseSession, nil
+}
+
+// GetSessionStats retrieves the session statistics of transmission.
+func (c *transmissionClient) GetSessionStats(ctx context.Context) (stats RPCResponseSessionStats, err error) {
+	var result rpcResponse
+	if result, err = c.call(ctx, "session-stats", nil); err != nil {
+		return stats, err
+	}
+	return result.Arguments.RPCResponseSessionStats, nil
+}
+
+// GetFreeSpace retrieves the available (and total, if supported) bytes of given directory.
+func (c *transmissionClient) GetFreeSpace(
+	ctx context.Context,
+	path string,
+) (freeSpace RPCResponseFreeSpace, err error) {
+	var result rpcResponse
+	if result, err = c.call(ctx, "free-space", map[string]any{
+		"path": path,
+	}); err != nil {
+		return freeSpace, err
+	}
+	return result.Arguments.RPCResponseFreeSpace, nil
+}
+
+// SetSession sets properties of the session with given arguments.
+func (c *transmissionClient) SetSession(
+	ctx context.Context,
+	arguments map[string]any,
+) (err error) {
+	_, err = c.call(ctx, "session-set", arguments)
+
+	return err
+}
+
+// GetSpeed retrieves the speed limits and current rates of transmission.
+func (c *transmissionClient) GetSpeed(ctx context.Context) string {
+	session, err := c.GetSession(ctx)
+	if err != nil {
+		return err.Error()
+	}
+	stats, err := c.GetSessionStats(ctx)
+	if err != nil {
+		return err.Error()
+	}
+
+	limit := func(enabled bool, kbps int64) string {
+		if enabled {
+			return fmt.Sprintf("%d KB/s", kbps)
+		}
+		return "unlimited"
+	}
+	turtle := "off"
+	if session.AltSpeedEnabled {
+		turtle = "on"
+	}
+
+	return strings.Join([]string{
+		"*current rates*",
+		fmt.Sprintf("  ┖ ↓%s/s ↑%s/s", readableSize(stats.DownloadSpeed), readableSize(stats.UploadSpeed)),
+		fmt.Sprintf("  ┖ active: %d, paused: %d, total: %d", stats.ActiveTorrentCount, stats.PausedTorrentCount, stats.TorrentCount),
+		"*speed limits*",
+		fmt.Sprintf("  ┖ download: %s", limit(session.SpeedLimitDownEnabled, session.SpeedLimitDown)),
+		fmt.Sprintf("  ┖ upload: %s", limit(session.SpeedLimitUpEnabled, session.SpeedLimitUp)),
+		fmt.Sprintf("  ┖ turtle mode: *%s* (↓%d KB/s ↑%d KB/s)", turtle, session.AltSpeedDown, session.AltSpeedUp),
+	}, "\n")
 }
 
 // convert given number to human-readable size string
@@ -391,20 +1214,26 @@ func readableSize(num int64) (str string) {
 	return str
 }
 
-// RemoveTorrent cancels/removes a torrent from the list.
-func RemoveTorrent(
-	port int,
-	username, passwd string,
-	torrentID string,
-) string {
-	return removeTorrent(port, username, passwd, torrentID, false)
+// convert given eta seconds to human-readable string
+func readableETA(eta int64) string {
+	switch {
+	case eta == -1:
+		return "not available"
+	case eta < 0:
+		return "unknown"
+	default:
+		return (time.Duration(eta) * time.Second).String()
+	}
 }
 
-// DeleteTorrent removes a torrent and its local data from the list.
-func DeleteTorrent(
-	port int,
-	username, passwd string,
-	torrentID string,
-) string {
-	return removeTorrent(port, username, passwd, torrentID, true)
+// convert given upload ratio to human-readable string
+func readableRatio(ratio float64) string {
+	switch {
+	case ratio == -1:
+		return "not available"
+	case ratio < 0:
+		return "∞"
+	default:
+		return fmt.Sprintf("%.2f", ratio)
+	}
 }
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// JSON-RPC 2.0 dialect of transmission RPC (transmission 4.1+)
+//
+// method names and keys are in snake_case (eg. `torrent_get`, `percent_done`)
+// instead of legacy kebab-case or camelCase (eg. `torrent-get`, `percentDone`).
+
+const (
+	// minimum `rpc-version` of daemons which speak JSON-RPC 2.0
+	minJSONRPCVersion = 19
+)
+
+// JSON-RPC 2.0 request
+type jsonRPCRequest struct {
+	JSONRPC string         `json:"jsonrpc"`
+	Method  string         `json:"method"`
+	Params  map[string]any `json:"params,omitempty"`
+	ID      int64          `json:"id"`
+}
+
+// JSON-RPC 2.0 response
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+	ID      int64           `json:"id"`
+}
+
+// JSON-RPC 2.0 error
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    *struct {
+		ErrorString string `json:"error_string,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// convert a legacy method name or key to snake_case
+//
+// (eg. `torrent-get` => `torrent_get`, `percentDone` => `percent_done`)
+func snakeCase(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '-':
+			sb.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// legacy keys of response objects, keyed by their snake_case names
+//
+// (a snake_case name can have multiple legacy keys, eg. `download_dir` => `download-dir` of session, `downloadDir` of torrent)
+var legacyKeys = sync.OnceValue(func() map[string][]string {
+	keys := map[string][]string{}
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice:
+			collect(t.Elem())
+		case reflect.Struct:
+			for i := range t.NumField() {
+				field := t.Field(i)
+				if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); len(name) > 0 && !slices.Contains(keys[snakeCase(name)], name) {
+					keys[snakeCase(name)] = append(keys[snakeCase(name)], name)
+				}
+				collect(field.Type)
+			}
+		}
+	}
+	collect(reflect.TypeFor[rpcResponseArgs]())
+
+	return keys
+})
+
+// convert keys of given value (and values of `fields`) to snake_case recursively
+func snakeCaseKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		converted := map[string]any{}
+		for key, value := range v {
+			if key == "fields" {
+				if fields, ok := value.([]string); ok {
+					snaked := []string{}
+					for _, field := range fields {
+						snaked = append(snaked, snakeCase(field))
+					}
+					value = snaked
+				}
+			}
+			converted[snakeCase(key)] = snakeCaseKeys(value)
+		}
+		return converted
+	case []any:
+		converted := []any{}
+		for _, value := range v {
+			converted = append(converted, snakeCaseKeys(value))
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+// convert snake_case keys of given value back to legacy ones recursively
+//
+// (values of keys which have multiple legacy keys are duplicated for all of them)
+func legacyCaseKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		converted := map[string]any{}
+		for key, value := range v {
+			value = legacyCaseKeys(value)
+			if legacy, exists := legacyKeys()[key]; exists {
+				for _, key := range legacy {
+					converted[key] = value
+				}
+			} else {
+				converted[key] = value
+			}
+		}
+		return converted
+	case []any:
+		converted := []any{}
+		for _, value := range v {
+			converted = append(converted, legacyCaseKeys(value))
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+// encode a request in JSON-RPC 2.0 dialect
+func encodeJSONRPCRequest(
+	id int64,
+	method string,
+	arguments map[string]any,
+) ([]byte, error) {
+	var params map[string]any
+	if arguments != nil {
+		params = snakeCaseKeys(arguments).(map[string]any)
+	}
+
+	return json.Marshal(jsonRPCRequest{
+		JSONRPC: "2.0",
+		Method:  snakeCase(method),
+		Params:  params,
+		ID:      id,
+	})
+}
+
+// decode a response in JSON-RPC 2.0 dialect, and map it onto the legacy one
+func decodeJSONRPCResponse(
+	method string,
+	res []byte,
+) (result rpcResponse, err error) {
+	var response jsonRPCResponse
+	if err = json.Unmarshal(res, &response); err != nil {
+		return result, fmt.Errorf("malformed RPC server response: %s", string(res))
+	}
+	if response.Error != nil {
+		message := response.Error.Message
+		if response.Error.Data != nil && len(response.Error.Data.ErrorString) > 0 {
+			message = fmt.Sprintf("%s (%s)", message, response.Error.Data.ErrorString)
+		}
+		return result, &RPCResultError{Method: method, Result: message}
+	}
+
+	result.Result = "success"
+	if len(response.Result) > 0 {
+		var v any
+		if err = json.Unmarshal(response.Result, &v); err != nil {
+			return result, fmt.Errorf("malformed RPC server response: %s", string(res))
+		}
+
+		var data []byte
+		if data, err = json.Marshal(legacyCaseKeys(v)); err == nil {
+			err = json.Unmarshal(data, &result.Arguments)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to convert RPC server response: %s", err)
+		}
+	}
+
+	return result, nil
+}
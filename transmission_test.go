@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+)
+
+// create a transmission client for given fake daemon
+func newTestTransmissionClient(t *testing.T, serverURL string, maxRetries, retryInterval int) *transmissionClient {
+	t.Helper()
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatalf("failed to parse url of fake daemon: %s", err)
+	}
+	host, port, _ := net.SplitHostPort(u.Host)
+	numPort, _ := strconv.Atoi(port)
+
+	return newTransmissionClient(cfg.TransmissionRPCEndpoint{
+		Scheme:        u.Scheme,
+		Host:          host,
+		Port:          numPort,
+		Path:          "/transmission/rpc",
+		MaxRetries:    maxRetries,
+		RetryInterval: retryInterval,
+	})
+}
+
+// decode the method of given legacy (or JSON-RPC 2.0) request
+func requestMethod(t *testing.T, r *http.Request) (method string, jsonRPC bool) {
+	t.Helper()
+
+	body, _ := io.ReadAll(r.Body)
+
+	var req struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Errorf("malformed request: %s", string(body))
+	}
+	return req.Method, req.JSONRPC == "2.0"
+}
+
+// respond to legacy `session-get` requests for `rpc-version`
+func writeRPCVersion(w http.ResponseWriter, rpcVersion int) {
+	_, _ = io.WriteString(w, `{"result":"success","arguments":{"rpc-version":`+strconv.Itoa(rpcVersion)+`}}`)
+}
+
+func TestTransmissionSessionIDRenewal(t *testing.T) {
+	const sessionID = "renewed-session-id"
+
+	var numConflicts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(httpHeaderXTransmissionSessionID) != sessionID {
+			numConflicts.Add(1)
+
+			w.Header().Set(httpHeaderXTransmissionSessionID, sessionID)
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		if method, _ := requestMethod(t, r); method == "session-get" {
+			writeRPCVersion(w, 17)
+			return
+		}
+		_, _ = io.WriteString(w, `{"result":"success","arguments":{"torrentCount":3}}`)
+	}))
+	defer server.Close()
+
+	c := newTestTransmissionClient(t, server.URL, 0, 10)
+
+	stats, err := c.GetSessionStats(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session stats: %s", err)
+	}
+	if stats.TorrentCount != 3 {
+		t.Errorf("expected torrent count 3, got %d", stats.TorrentCount)
+	}
+	if c.getSessionID() != sessionID {
+		t.Errorf("expected session id '%s', got '%s'", sessionID, c.getSessionID())
+	}
+	if n := numConflicts.Load(); n != 1 {
+		t.Errorf("expected only 1 conflict, got %d", n)
+	}
+}
+
+func TestTransmissionSessionIDConflict(t *testing.T) {
+	// (session id is renewed on every request, so it can never be negotiated)
+	var numRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(httpHeaderXTransmissionSessionID, strconv.Itoa(int(numRequests.Add(1))))
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	c := newTestTransmissionClient(t, server.URL, 0, 10)
+
+	if _, err := c.GetSessionStats(context.Background()); !errors.Is(err, ErrRPCConflict) {
+		t.Errorf("expected ErrRPCConflict, got %v", err)
+	}
+	if n := numRequests.Load(); n != numRetries+1 {
+		t.Errorf("expected %d requests, got %d", numRetries+1, n)
+	}
+}
+
+func TestTransmissionUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := newTestTransmissionClient(t, server.URL, 3, 10)
+
+	if _, err := c.GetSessionStats(context.Background()); !errors.Is(err, ErrRPCUnauthorized) {
+		t.Errorf("expected ErrRPCUnauthorized, got %v", err)
+	}
+}
+
+func TestTransmissionUnreachable(t *testing.T) {
+	// (url of a daemon which is not running)
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := server.URL
+	server.Close()
+
+	const maxRetries, retryInterval = 2, 20
+
+	c := newTestTransmissionClient(t, serverURL, maxRetries, retryInterval)
+
+	startedAt := time.Now()
+	if _, err := c.GetSessionStats(context.Background()); !errors.Is(err, ErrRPCUnreachable) {
+		t.Errorf("expected ErrRPCUnreachable, got %v", err)
+	}
+
+	// retried with backoff: 20ms + 40ms
+	if elapsed, expected := time.Since(startedAt), (retryInterval+retryInterval*2)*time.Millisecond; elapsed < expected {
+		t.Errorf("expected retries with backoff for at least %s, but returned in %s", expected, elapsed)
+	}
+}
+
+func TestTransmissionUnreachableCanceled(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := server.URL
+	server.Close()
+
+	// (would be retried for a long time without cancellation)
+	c := newTestTransmissionClient(t, serverURL, 10, 1000)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+	if _, err := c.GetSessionStats(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(startedAt); elapsed > time.Second {
+		t.Errorf("expected to return soon after cancellation, but returned in %s", elapsed)
+	}
+}
+
+func TestTransmissionResultError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if method, _ := requestMethod(t, r); method == "session-get" {
+			writeRPCVersion(w, 17)
+			return
+		}
+		_, _ = io.WriteString(w, `{"result":"invalid or corrupt torrent file"}`)
+	}))
+	defer server.Close()
+
+	c := newTestTransmissionClient(t, server.URL, 0, 10)
+
+	_, err := c.GetSessionStats(context.Background())
+
+	var resultErr *RPCResultError
+	if !errors.As(err, &resultErr) {
+		t.Fatalf("expected RPCResultError, got %v", err)
+	}
+	if resultErr.Method != "session-stats" || resultErr.Result != "invalid or corrupt torrent file" {
+		t.Errorf("unexpected RPCResultError: %+v", resultErr)
+	}
+}
+
+func TestTransmissionJSONRPC(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, jsonRPC := requestMethod(t, r)
+		if method == "session-get" && !jsonRPC {
+			writeRPCVersion(w, minJSONRPCVersion)
+			return
+		}
+
+		if !jsonRPC || method != "session_stats" {
+			t.Errorf("expected JSON-RPC 2.0 request of 'session_stats', got '%s' (JSON-RPC 2.0: %t)", method, jsonRPC)
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"download_speed":100,"torrent_count":3}}`)
+	}))
+	defer server.Close()
+
+	c := newTestTransmissionClient(t, server.URL, 0, 10)
+
+	stats, err := c.GetSessionStats(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get session stats: %s", err)
+	}
+	if stats.DownloadSpeed != 100 || stats.TorrentCount != 3 {
+		t.Errorf("unexpected session stats: %+v", stats)
+	}
+}
+
+func TestTransmissionJSONRPCError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if method, jsonRPC := requestMethod(t, r); method == "session-get" && !jsonRPC {
+			writeRPCVersion(w, minJSONRPCVersion)
+			return
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`)
+	}))
+	defer server.Close()
+
+	c := newTestTransmissionClient(t, server.URL, 0, 10)
+
+	var resultErr *RPCResultError
+	if _, err := c.GetSessionStats(context.Background()); !errors.As(err, &resultErr) {
+		t.Errorf("expected RPCResultError, got %v", err)
+	}
+}
+
+func TestSnakeCaseKeys(t *testing.T) {
+	converted := snakeCaseKeys(map[string]any{
+		"ids":               []any{1, 2},
+		"delete-local-data": true,
+		"fields":            []string{"hashString", "percentDone"},
+		"trackerList":       "",
+	})
+
+	expected := map[string]any{
+		"ids":               []any{1, 2},
+		"delete_local_data": true,
+		"fields":            []string{"hash_string", "percent_done"},
+		"tracker_list":      "",
+	}
+	if !reflect.DeepEqual(converted, expected) {
+		t.Errorf("expected %v, got %v", expected, converted)
+	}
+}
+
+func TestLegacyCaseKeys(t *testing.T) {
+	converted := legacyCaseKeys(map[string]any{
+		"torrents": []any{
+			map[string]any{
+				"hash_string":   "abcd",
+				"percent_done":  0.5,
+				"tracker_stats": []any{map[string]any{"last_announce_succeeded": true}},
+			},
+		},
+		"rpc_version":   float64(19),
+		"unknown_key":   "kept as it is",
+		"torrent_added": map[string]any{"id": float64(1)},
+		"download_dir":  "/downloads", // (`download-dir` of session, and `downloadDir` of torrent)
+	})
+
+	expected := map[string]any{
+		"torrents": []any{
+			map[string]any{
+				"hashString":   "abcd",
+				"percentDone":  0.5,
+				"trackerStats": []any{map[string]any{"lastAnnounceSucceeded": true}},
+			},
+		},
+		"rpc-version":   float64(19),
+		"unknown_key":   "kept as it is",
+		"torrent-added": map[string]any{"id": float64(1)},
+		"download-dir":  "/downloads",
+		"downloadDir":   "/downloads",
+	}
+	if !reflect.DeepEqual(converted, expected) {
+		t.Errorf("expected %v, got %v", expected, converted)
+	}
+}
+
+func TestJSONRPCRoundTrip(t *testing.T) {
+	// legacy keys => snake_case keys (request) => legacy keys (response)
+	roundTrip := func(v, decoded any) {
+		t.Helper()
+
+		var m any
+		data, _ := json.Marshal(v)
+		_ = json.Unmarshal(data, &m)
+
+		data, _ = json.Marshal(legacyCaseKeys(snakeCaseKeys(m)))
+		if err := json.Unmarshal(data, decoded); err != nil {
+			t.Fatalf("failed to decode round-tripped value: %s", err)
+		}
+	}
+
+	torrent := RPCResponseTorrent{
+		ID:             1,
+		Name:           "name",
+		HashString:     "abcd",
+		PercentDone:    0.5,
+		TotalSize:      1024,
+		RateDownload:   100,
+		DownloadDir:    "/downloads",
+		UploadRatio:    1.5,
+		SecondsSeeding: 60,
+		TrackerStats: []RPCResponseTorrentTracker{
+			{ID: 1, Announce: "https://tracker.example.com/announce", LastAnnounceSucceeded: true},
+		},
+	}
+	var decodedTorrent RPCResponseTorrent
+	if roundTrip(torrent, &decodedTorrent); !reflect.DeepEqual(decodedTorrent, torrent) {
+		t.Errorf("expected %+v, got %+v", torrent, decodedTorrent)
+	}
+
+	session := RPCResponseSession{RPCVersion: 19, DownloadDir: "/downloads"}
+	var decodedSession RPCResponseSession
+	if roundTrip(session, &decodedSession); !reflect.DeepEqual(decodedSession, session) {
+		t.Errorf("expected %+v, got %+v", session, decodedSession)
+	}
+
+	freeSpace := RPCResponseFreeSpace{Path: "/downloads", SizeBytes: 1, TotalSize: 2}
+	var decodedFreeSpace RPCResponseFreeSpace
+	if roundTrip(freeSpace, &decodedFreeSpace); !reflect.DeepEqual(decodedFreeSpace, freeSpace) {
+		t.Errorf("expected %+v, got %+v", freeSpace, decodedFreeSpace)
+	}
+}
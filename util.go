@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"os/exec"
 	"strings"
 	"syscall"
@@ -36,10 +37,7 @@ func diskUsage(additionalPaths []string) (usage string) {
 
 	var lines []string
 	for _, p := range paths {
-		fs := syscall.Statfs_t{}
-		if err := syscall.Statfs(p, &fs); err == nil {
-			all := fs.Blocks * uint64(fs.Bsize)
-			free := fs.Bavail * uint64(fs.Bsize)
+		if all, free, err := diskSpace(p); err == nil {
 			used := all - free
 
 			lines = append(lines, fmt.Sprintf(
@@ -57,6 +55,25 @@ func diskUsage(additionalPaths []string) (usage string) {
 	return strings.Join(lines, "\n")
 }
 
+// checks if given host is the local machine
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// returns total and available bytes of the file system which contains given path
+func diskSpace(path string) (all, free uint64, err error) {
+	fs := syscall.Statfs_t{}
+	if err = syscall.Statfs(path, &fs); err == nil {
+		all = fs.Blocks * uint64(fs.Bsize)
+		free = fs.Bavail * uint64(fs.Bsize)
+	}
+	return all, free, err
+}
+
 // removes markdown characters for avoiding
 // 'Bad Request: Can't parse message text: Can't find end of the entity starting at byte offset ...' errors
 // from the server
@@ -67,6 +84,43 @@ func removeMarkdownChars(original, replaceWith string) string {
 	return removed
 }
 
+// splits given lines into pages, each of which does not exceed `maxLength` bytes
+//
+// (a line longer than `maxLength` will be truncated)
+func paginateLines(lines []string, maxLength int) (pages []string) {
+	page := []string{}
+	length := 0
+	for _, line := range lines {
+		if len(line) > maxLength {
+			line = strings.ToValidUTF8(line[:maxLength], "")
+		}
+
+		if length+len(line)+1 > maxLength && len(page) > 0 {
+			pages = append(pages, strings.Join(page, "\n"))
+
+			page = []string{}
+			length = 0
+		}
+
+		page = append(page, line)
+		length += len(line) + 1
+	}
+	if len(page) > 0 {
+		pages = append(pages, strings.Join(page, "\n"))
+	}
+
+	return pages
+}
+
+// truncates given string to `maxLength` characters (runes)
+func truncateString(str string, maxLength int) string {
+	runes := []rune(str)
+	if len(runes) > maxLength {
+		return string(runes[:maxLength-1]) + "…"
+	}
+	return str
+}
+
 // `systemctl status is-active`
 func systemctlStatus(services []string) (statuses map[string]string, success bool) {
 	statuses = make(map[string]string)
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// cancel functions of running watchers, keyed by their chat and message ids
+var (
+	torrentWatchers      = map[string]context.CancelFunc{}
+	torrentWatchersMutex sync.Mutex
+)
+
+// torrents to watch
+type watchTarget struct {
+	Instances    []cfg.TransmissionInstance
+	TorrentIDs   []int // only these torrents will be watched when given
+	Verification bool  // only verifying torrents will be considered active when true
+}
+
+// generate the key of a watcher
+func watcherKey(chatID, messageID int64) string {
+	return fmt.Sprintf("%d/%d", chatID, messageID)
+}
+
+// parse transmission watch command, and return the target to watch
+// (of all instances, or of the instance specified with `@name`)
+// along with the first message and its inline keyboards
+//
+// `/trwatch [@instance]`
+//
+// (no target will be returned when there is nothing to watch)
+func parseTransmissionWatchCommand(
+	ctx context.Context,
+	config cfg.Config,
+	txt string,
+) (message string, keyboards [][]bot.InlineKeyboardButton, target *watchTarget) {
+	target = &watchTarget{
+		Instances: config.TransmissionInstances,
+	}
+	if instance, _, found := extractInstance(config, txt); found {
+		target.Instances = []cfg.TransmissionInstance{instance}
+	}
+
+	message, numActive := torrentProgress(ctx, config, *target)
+	if numActive <= 0 {
+		return message, nil, nil
+	}
+
+	return message, watchKeyboards(), target
+}
+
+// generate inline keyboards for watching torrents
+func watchKeyboards() [][]bot.InlineKeyboardButton {
+	return [][]bot.InlineKeyboardButton{
+		{
+			bot.NewInlineKeyboardButton(consts.MessageStopWatching).
+				SetCallbackData(fmt.Sprintf("%s %s", consts.CommandTransmissionWatch, consts.ParamStopWatching)).
+				SetStyle(bot.KeyboardStyleDanger),
+		},
+	}
+}
+
+// check if given torrent is active (downloading or verifying) for the target
+func (w watchTarget) isActive(t RPCResponseTorrent) bool {
+	if len(w.TorrentIDs) > 0 && !slices.Contains(w.TorrentIDs, t.ID) {
+		return false
+	}
+	if len(t.Error) > 0 {
+		return false
+	}
+
+	switch t.Status {
+	case TorrentStatusQueuedToVerifyLocalData, TorrentStatusVerifyingLocalData:
+		return true
+	case TorrentStatusQueuedToDownload, TorrentStatusDownloading:
+		return !w.Verification && t.PercentDone < 1.0
+	}
+	return false
+}
+
+// generate a message with the progress of active torrents of given target,
+// and return it with the number of active torrents
+//
+// (only some of them will be shown for not exceeding the length limit of messages)
+func torrentProgress(
+	ctx context.Context,
+	config cfg.Config,
+	target watchTarget,
+) (message string, numActive int) {
+	lines := []string{}
+	length, numShown := 0, 0
+	add := func(line string) {
+		if numShown < consts.NumSelectedTorrentsToShow && length+len(line)+1 <= consts.MaxMessageLength {
+			lines = append(lines, line)
+			length += len(line) + 1
+			numShown++
+		}
+		numActive++
+	}
+	for _, instance := range target.Instances {
+		torrents, err := torrentClientFor(instance).GetTorrents(ctx)
+		if err != nil {
+			line := withInstanceName(config, instance, err.Error())
+			lines = append(lines, line)
+			length += len(line) + 1
+			continue
+		}
+
+		for _, t := range torrents {
+			if !target.isActive(t) {
+				continue
+			}
+
+			// progress of verification
+			if t.Status == TorrentStatusQueuedToVerifyLocalData || t.Status == TorrentStatusVerifyingLocalData {
+				add(withInstanceName(config, instance, fmt.Sprintf(
+					`*%d*. _%s_
+  ┖ %s %s %.2f%%`,
+					t.ID,
+					removeMarkdownChars(t.Name, " "),
+					statusToString(t.Status),
+					progressBar(t.RecheckProgress),
+					t.RecheckProgress*100.0,
+				)))
+				continue
+			}
+
+			// calculate eta with the current rate
+			eta := int64(-1)
+			if t.RateDownload > 0 {
+				eta = int64(float64(t.TotalSize)*(1.0-float64(t.PercentDone))) / t.RateDownload
+			}
+
+			add(withInstanceName(config, instance, fmt.Sprintf(
+				`*%d*. _%s_
+  ┖ %s %s %.2f%%
+  ┖ ↓%s/s ↑%s/s, eta: %s`,
+				t.ID,
+				removeMarkdownChars(t.Name, " "),
+				statusToString(t.Status),
+				progressBar(t.PercentDone),
+				t.PercentDone*100.0,
+				readableSize(t.RateDownload),
+				readableSize(t.RateUpload),
+				readableETA(eta),
+			)))
+		}
+	}
+
+	if numActive <= 0 {
+		lines = append(lines, consts.MessageTransmissionNoActiveTorrents)
+	} else {
+		if numShown < numActive {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", numActive-numShown))
+		}
+		lines = append(lines, `----`, fmt.Sprintf("%d active torrent(s), updated at %s", numActive, time.Now().Format("15:04:05")))
+	}
+
+	return strings.Join(lines, "\n"), numActive
+}
+
+// generate a progress bar for given percentage (0.0 ~ 1.0)
+func progressBar(percent float32) string {
+	filled := max(0, min(consts.ProgressBarLength, int(percent*consts.ProgressBarLength)))
+
+	return strings.Repeat(`█`, filled) + strings.Repeat(`░`, consts.ProgressBarLength-filled)
+}
+
+// keep editing given message with the progress of active torrents of given target,
+// until all of them finish, timeout, or stopped by the user
+func startWatching(
+	ctx context.Context,
+	b *bot.Bot,
+	config cfg.Config,
+	db *Database,
+	chatID, messageID int64,
+	message string,
+	target watchTarget,
+) {
+	ctxWatch, cancelWatch := context.WithTimeout(ctx, consts.WatchTimeoutMinutes*time.Minute)
+
+	key := watcherKey(chatID, messageID)
+	torrentWatchersMutex.Lock()
+	torrentWatchers[key] = cancelWatch
+	torrentWatchersMutex.Unlock()
+
+	go func() {
+		defer func() {
+			torrentWatchersMutex.Lock()
+			delete(torrentWatchers, key)
+			torrentWatchersMutex.Unlock()
+
+			cancelWatch()
+		}()
+
+		ticker := time.NewTicker(consts.WatchIntervalSeconds * time.Second)
+		defer ticker.Stop()
+
+		lastMessage := message
+		for {
+			select {
+			case <-ctxWatch.Done():
+				if errors.Is(ctxWatch.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+					editWatchMessage(ctx, b, db, chatID, messageID, fmt.Sprintf("%s\n\n%s", lastMessage, consts.MessageWatchTimedOut), nil)
+				}
+				return
+			case <-ticker.C:
+				message, numActive := torrentProgress(ctxWatch, config, target)
+				if ctxWatch.Err() != nil {
+					continue // (will be handled in the next loop)
+				}
+
+				if numActive <= 0 {
+					finished := consts.MessageWatchFinished
+					if target.Verification {
+						finished = consts.MessageVerificationFinished
+					}
+					editWatchMessage(ctx, b, db, chatID, messageID, finished, nil)
+					return
+				}
+				if message != lastMessage { // (editing with the same text will fail)
+					editWatchMessage(ctxWatch, b, db, chatID, messageID, message, watchKeyboards())
+					lastMessage = message
+				}
+			}
+		}
+	}()
+}
+
+// stop the watcher of given message
+//
+// (returns false if there is no such watcher, eg. already finished)
+func stopWatching(chatID, messageID int64) bool {
+	torrentWatchersMutex.Lock()
+	defer torrentWatchersMutex.Unlock()
+
+	if cancel, exists := torrentWatchers[watcherKey(chatID, messageID)]; exists {
+		cancel()
+		return true
+	}
+	return false
+}
+
+// edit the message of a watcher
+func editWatchMessage(
+	ctx context.Context,
+	b *bot.Bot,
+	db *Database,
+	chatID, messageID int64,
+	message string,
+	keyboards [][]bot.InlineKeyboardButton,
+) {
+	options := bot.OptionsEditMessageText{}.
+		SetIDs(chatID, messageID)
+	if keyboards != nil {
+		options.SetReplyMarkup(bot.NewInlineKeyboardMarkup(keyboards))
+	}
+	if checkMarkdownValidity(message) {
+		options.SetParseMode(bot.ParseModeMarkdown)
+	}
+
+	ctxEdit, cancelEdit := context.WithTimeout(ctx, requestTimeoutSeconds*time.Second)
+	defer cancelEdit()
+	if apiResult, _ := b.EditMessageText(ctxEdit, message, options); !apiResult.OK && ctx.Err() == nil {
+		description := "unknown error"
+		if apiResult.Description != nil {
+			description = *apiResult.Description
+		}
+		logError(db, "failed to edit watch message: %s", description)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	bot "github.com/meinside/telegram-bot-go"
+	"github.com/meinside/telegram-remotecontrol-bot/cfg"
+	"github.com/meinside/telegram-remotecontrol-bot/consts"
+)
+
+// run a loop which watches configured folders and adds torrents of .torrent/.magnet files saved in them
+func runFolderWatcher(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logError(db, "failed to create watcher for watch folders: %s", err)
+		return
+	}
+	defer func() { _ = watcher.Close() }()
+
+	folders := map[string]cfg.WatchFolder{}
+	for _, folder := range config.WatchFolders {
+		path := filepath.Clean(folder.Path)
+		if err := watcher.Add(path); err != nil {
+			logError(db, "failed to watch folder '%s': %s", path, err)
+			continue
+		}
+		folders[path] = folder
+
+		_stdout.Printf("watching folder: %s", path)
+
+		// process files which were saved before launch
+		if entries, err := os.ReadDir(path); err == nil {
+			for _, entry := range entries {
+				if !entry.IsDir() && isWatchedFile(entry.Name()) {
+					processWatchedFile(ctx, client, config, db, folder, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	// (files will be processed after they are not written for a while)
+	pending := map[string]*time.Timer{}
+	settled := make(chan string)
+	settle := consts.WatchFolderSettleMilliseconds * time.Millisecond
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, timer := range pending {
+				timer.Stop()
+			}
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if (!event.Has(fsnotify.Create) && !event.Has(fsnotify.Write)) || !isWatchedFile(event.Name) {
+				continue
+			}
+
+			if timer, exists := pending[event.Name]; exists {
+				timer.Reset(settle)
+			} else {
+				path := event.Name
+				pending[path] = time.AfterFunc(settle, func() {
+					select {
+					case settled <- path:
+					case <-ctx.Done():
+					}
+				})
+			}
+		case path := <-settled:
+			delete(pending, path)
+
+			if folder, exists := folders[filepath.Dir(path)]; exists {
+				processWatchedFile(ctx, client, config, db, folder, path)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logError(db, "error while watching folders: %s", err)
+		}
+	}
+}
+
+// check if given file should be processed
+func isWatchedFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".torrent", ".magnet":
+		return !strings.HasPrefix(filepath.Base(path), ".")
+	}
+	return false
+}
+
+// add a torrent of given file, move the file into the done (or failed) subfolder,
+// and notify the result to chats
+func processWatchedFile(
+	ctx context.Context,
+	client *bot.Bot,
+	config cfg.Config,
+	db *Database,
+	folder cfg.WatchFolder,
+	path string,
+) {
+	// (file could be removed or moved already)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	instance := instanceNamed(config, folder.Instance)
+
+	var message string
+	var added bool
+	if torrent, err := readWatchedFile(path); err == nil {
+		message, added = addTorrentCheckingDiskUsage(ctx, client, config, instance, db, torrent, folder.DownloadDir, false)
+	} else {
+		message = withInstanceName(config, instance, err.Error())
+	}
+
+	subfolder := consts.WatchFolderDoneDir
+	if !added {
+		subfolder = consts.WatchFolderFailedDir
+	}
+	if err := moveIntoSubfolder(path, subfolder); err != nil {
+		logError(db, "failed to move processed file '%s': %s", path, err)
+	}
+
+	broadcast(ctx, client, config, db, fmt.Sprintf("📂 _%s_\n  ┖ %s", removeMarkdownChars(filepath.Base(path), " "), message))
+}
+
+// read a torrent from given .torrent or .magnet file
+func readWatchedFile(path string) (torrent TorrentSource, err error) {
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		return torrent, fmt.Errorf("failed to open file: %s", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if strings.ToLower(filepath.Ext(path)) == ".magnet" {
+		var bytes []byte
+		if bytes, err = io.ReadAll(io.LimitReader(file, consts.MaxMagnetFileSize)); err != nil {
+			return torrent, fmt.Errorf("failed to read file: %s", err)
+		}
+		for line := range strings.Lines(string(bytes)) {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "magnet:") {
+				torrent.Filename = line
+				return torrent, nil
+			}
+		}
+		return torrent, fmt.Errorf("no magnet link in file")
+	}
+
+	if torrent.Metainfo, err = io.ReadAll(io.LimitReader(file, consts.MaxTorrentFileSize+1)); err != nil {
+		return torrent, fmt.Errorf("failed to read file: %s", err)
+	}
+	if len(torrent.Metainfo) > consts.MaxTorrentFileSize {
+		return torrent, fmt.Errorf("file is too large: > %s", readableSize(consts.MaxTorrentFileSize))
+	}
+	if _, err = parseMetainfo(torrent.Metainfo); err != nil {
+		return torrent, fmt.Errorf("not a valid .torrent file: %s", err)
+	}
+
+	return torrent, nil
+}
+
+// move given file into a subfolder of its directory
+//
+// (timestamp will be prepended to its name when there is a file with the same name)
+func moveIntoSubfolder(path, subfolder string) error {
+	dir := filepath.Join(filepath.Dir(path), subfolder)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(dest); err == nil {
+		dest = filepath.Join(dir, fmt.Sprintf("%s-%s", time.Now().Format("20060102150405"), filepath.Base(path)))
+	}
+
+	return os.Rename(path, dest)
+}